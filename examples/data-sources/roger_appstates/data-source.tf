@@ -0,0 +1,5 @@
+data "roger_appstates" "all" {}
+
+output "appstates" {
+  value = data.roger_appstates.all.appstates
+}
@@ -0,0 +1,8 @@
+data "roger_state" "web" {
+  hostname      = "myhostname.cern.ch"
+  allow_missing = true
+}
+
+output "in_production" {
+  value = data.roger_state.web.appstate == "production"
+}
@@ -0,0 +1,8 @@
+data "roger_states" "draining_batch" {
+  appstate         = "draining"
+  hostname_pattern = "batch*.cern.ch"
+}
+
+output "draining_batch_hosts" {
+  value = data.roger_states.draining_batch.hostnames
+}
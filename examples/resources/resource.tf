@@ -3,3 +3,13 @@ resource "roger_state" "my_state" {
   message  = "my message"
   appstate = "production"
 }
+
+resource "roger_state" "intervention" {
+  hostname    = "otherhost.cern.ch"
+  message     = "hardware intervention"
+  appstate    = "draining"
+  hw_alarmed  = false
+  app_alarmed = false
+  expires     = "4h"
+  on_destroy  = "restore"
+}
@@ -0,0 +1,5 @@
+resource "roger_alarms" "maintenance" {
+  hostname   = "myhostname.cern.ch"
+  hw_alarmed = false
+  os_alarmed = false
+}
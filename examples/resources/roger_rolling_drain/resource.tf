@@ -0,0 +1,9 @@
+resource "roger_rolling_drain" "reboot" {
+  hostnames               = ["web001.cern.ch", "web002.cern.ch", "web003.cern.ch", "web004.cern.ch"]
+  max_unavailable_percent = 25
+  pause                   = "10m"
+  message                 = "rolling reboot"
+
+  # Change to return the hosts to their previous appstate, e.g. once the reboots are done.
+  restore_trigger = "v1"
+}
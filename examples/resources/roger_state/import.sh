@@ -0,0 +1,2 @@
+# roger_state can be imported by the hostname of the roger entry.
+terraform import roger_state.my_state myhostname.cern.ch
@@ -0,0 +1,12 @@
+resource "roger_states" "batch" {
+  parallelism = 20
+
+  states = {
+    for host in ["batch001.cern.ch", "batch002.cern.ch", "batch003.cern.ch"] :
+    host => {
+      appstate   = "draining"
+      message    = "kernel upgrade"
+      hw_alarmed = false
+    }
+  }
+}
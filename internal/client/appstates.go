@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// DefaultAppStates are the appstates used when the roger server does not provide its catalogue.
+var DefaultAppStates = []string{"production", "draining", "quiesce"}
+
+// WithAllowedAppStates replaces the appstate catalogue of the server for sites with custom appstates.
+func WithAllowedAppStates(states ...string) Option {
+	return func(c *Client) {
+		c.allowedAppStates = states
+	}
+}
+
+// AppStates returns the appstates accepted by roger. Unless WithAllowedAppStates was given, the
+// catalogue is fetched from the server once and cached. If the server does not provide it, i.e.
+// answers 404 or 501, DefaultAppStates are used instead. Other errors are returned without caching,
+// so that the catalogue is fetched again by the next call.
+func (c *Client) AppStates(ctx context.Context) ([]string, error) {
+	ctx = c.logContext(ctx)
+	if len(c.allowedAppStates) > 0 {
+		return c.allowedAppStates, nil
+	}
+
+	c.appStatesMu.Lock()
+	defer c.appStatesMu.Unlock()
+
+	if c.appStates != nil {
+		return c.appStates, nil
+	}
+
+	states, err := c.fetchAppStates(ctx)
+	if hasStatus(err, http.StatusNotFound, http.StatusNotImplemented) {
+		c.logger().Warn(ctx, "roger does not provide appstates, using built-in list", map[string]any{
+			"error": err.Error(),
+		})
+		states, err = DefaultAppStates, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch appstates: %w", err)
+	}
+	c.appStates = states
+	return states, nil
+}
+
+func (c *Client) fetchAppStates(ctx context.Context) ([]string, error) {
+	url := c.endpointURL("appstates")
+
+	body, _, err := c.doRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The catalogue is either a list of names or a list of objects with a name.
+	var list []string
+	if err := json.Unmarshal(body, &list); err == nil {
+		return nonEmpty(list)
+	}
+
+	var entries []struct {
+		Name     string `json:"name"`
+		AppState string `json:"appstate"`
+	}
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Name != "" {
+			names = append(names, entry.Name)
+		} else {
+			names = append(names, entry.AppState)
+		}
+	}
+	return nonEmpty(names)
+}
+
+func nonEmpty(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("roger returned no appstates")
+	}
+	return names, nil
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	roger "roger/internal/client"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppStatesFetchedOnceFromServer(t *testing.T) {
+	var calls atomic.Int32
+	cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		require.Equal(t, "/roger/v1/appstates/", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name": "production"}, {"name": "maintenance"}]`))
+	}))
+
+	for range 2 {
+		states, err := cli.AppStates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"production", "maintenance"}, states)
+	}
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestAppStatesFallBackToBuiltInList(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusNotImplemented} {
+		var calls atomic.Int32
+		cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(status)
+		}))
+
+		for range 2 {
+			states, err := cli.AppStates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, roger.DefaultAppStates, states)
+		}
+		require.EqualValues(t, 1, calls.Load(), "fallback for status %d must be cached", status)
+	}
+}
+
+func TestAppStatesErrorsNotCached(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusInternalServerError} {
+		var calls atomic.Int32
+		cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`["production", "maintenance"]`))
+		}))
+
+		_, err := cli.AppStates(context.Background())
+		require.Error(t, err)
+
+		states, err := cli.AppStates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"production", "maintenance"}, states)
+		require.EqualValues(t, 2, calls.Load())
+	}
+}
+
+func TestAppStatesConfigured(t *testing.T) {
+	var calls atomic.Int32
+	cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	roger.WithAllowedAppStates("production", "maintenance")(cli)
+
+	states, err := cli.AppStates(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"production", "maintenance"}, states)
+	require.Zero(t, calls.Load())
+}
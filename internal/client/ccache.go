@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jcmturner/gokrb5/v8/credentials"
+)
+
+// loadCCache loads the credential cache named by KRB5CCNAME, falling back to
+// default_ccache_name of krb5.conf and finally to /tmp/krb5cc_<uid>.
+// Only FILE: and DIR: caches can be read, other types are stored outside of
+// the file system and are not supported by gokrb5.
+func loadCCache() (*credentials.CCache, error) {
+	name, err := ccacheName()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := resolveCCachePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ccache, err := credentials.LoadCCache(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential cache %q: %w", path, err)
+	}
+	return ccache, nil
+}
+
+func ccacheName() (string, error) {
+	if name := os.Getenv("KRB5CCNAME"); name != "" {
+		return name, nil
+	}
+
+	name, err := defaultCCacheName(krb5ConfigPath())
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = "FILE:/tmp/krb5cc_%{uid}"
+	}
+	return expandCCacheName(name), nil
+}
+
+// defaultCCacheName reads default_ccache_name from the [libdefaults] section of krb5.conf,
+// as gokrb5 does not parse it.
+func defaultCCacheName(confPath string) (string, error) {
+	f, err := os.Open(confPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read %s: %w", confPath, err)
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+		if section != "libdefaults" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == "default_ccache_name" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// expandCCacheName expands the parameters supported in krb5.conf cache names.
+func expandCCacheName(name string) string {
+	return strings.NewReplacer(
+		"%{uid}", strconv.Itoa(os.Getuid()),
+		"%{euid}", strconv.Itoa(os.Geteuid()),
+		"%{TEMP}", os.TempDir(),
+	).Replace(name)
+}
+
+// resolveCCachePath turns a TYPE:residual cache name into the path of a cache file.
+func resolveCCachePath(name string) (string, error) {
+	cacheType, residual, found := strings.Cut(name, ":")
+	if !found || filepath.IsAbs(name) {
+		return name, nil
+	}
+
+	switch strings.ToUpper(cacheType) {
+	case "FILE":
+		return residual, nil
+	case "DIR":
+		return resolveDirCCache(residual)
+	default:
+		return "", fmt.Errorf("credential cache %q has unsupported type %s, only FILE: and DIR: caches are supported; "+
+			"use a file cache, e.g. KRB5CCNAME=FILE:/tmp/krb5cc_%d, or configure a keytab or password", name, cacheType, os.Getuid())
+	}
+}
+
+// resolveDirCCache resolves a DIR: collection. "DIR::<file>" names a cache of the collection
+// directly, "DIR:<dir>" uses the primary cache named in <dir>/primary.
+func resolveDirCCache(residual string) (string, error) {
+	if file, ok := strings.CutPrefix(residual, ":"); ok {
+		return file, nil
+	}
+
+	primary, err := os.ReadFile(filepath.Join(residual, "primary"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return filepath.Join(residual, "tkt"), nil
+		}
+		return "", fmt.Errorf("failed to read primary cache of collection %s: %w", residual, err)
+	}
+
+	name := strings.TrimSpace(string(primary))
+	if name == "" || strings.ContainsRune(name, filepath.Separator) {
+		return "", fmt.Errorf("invalid primary cache %q in collection %s", name, residual)
+	}
+	return filepath.Join(residual, name), nil
+}
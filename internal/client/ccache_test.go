@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	roger "roger/internal/client"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsupportedCCacheType(t *testing.T) {
+	writeKrb5Conf(t)
+	t.Setenv("KRB5CCNAME", "KEYRING:persistent:1000")
+
+	_, err := roger.NewClient(context.Background(), "localhost", 8201)
+	require.ErrorContains(t, err, "unsupported type KEYRING")
+}
+
+func TestDirCCacheUsesPrimary(t *testing.T) {
+	writeKrb5Conf(t)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "primary"), []byte("tktABC\n"), 0o600))
+	t.Setenv("KRB5CCNAME", "DIR:"+dir)
+
+	_, err := roger.NewClient(context.Background(), "localhost", 8201)
+	require.ErrorContains(t, err, filepath.Join(dir, "tktABC"))
+}
@@ -6,46 +6,110 @@ package roger
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jcmturner/gokrb5/v8/client"
 	"github.com/jcmturner/gokrb5/v8/config"
-	"github.com/jcmturner/gokrb5/v8/credentials"
 	"github.com/jcmturner/gokrb5/v8/spnego"
 )
 
 type Client struct {
-	HTTPClient *spnego.Client
-	Host       string
-	Port       int
+	HTTPClient   *spnego.Client
+	Host         string
+	Port         int
+	BaseURL      *url.URL
+	Retry        RetryPolicy
+	Logger       Logger
+	MaskedFields []string
+
+	principal  string
+	keytabPath string
+	password   string
+
+	endpoint             string
+	resolver             Resolver
+	skipCanonicalization bool
+	spn                  string
+	tlsOptions           TLSOptions
+	transport            http.RoundTripper
+
+	allowedAppStates []string
+	// appStates caches the catalogue of the server, guarded by appStatesMu.
+	appStates   []string
+	appStatesMu sync.Mutex
+
+	auth *kerberosAuth
+	// patchUnsupported is set once the server rejected a PATCH request.
+	patchUnsupported atomic.Bool
+	// mu guards HTTPClient, which is replaced whenever a new ticket is acquired.
+	mu sync.RWMutex
 }
 
-func loadKrb5Config() (*config.Config, error) {
+// Option customises a Client created by NewClient.
+type Option func(*Client)
+
+// WithRetryPolicy sets the policy used to retry transient roger API failures.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.Retry = policy
+	}
+}
+
+func krb5ConfigPath() string {
 	path := os.Getenv("KRB5_CONFIG")
 	if path == "" {
 		path = "/etc/krb5.conf" // Default path for krb5.conf
 	}
-	return config.Load(path)
+	return path
 }
 
-func loadCCache() (*credentials.CCache, error) {
-	ccachePath := os.Getenv("KRB5CCNAME")
-	if ccachePath == "" {
-		return nil, fmt.Errorf("KRB5CCNAME environment variable not set")
+func loadKrb5Config() (*config.Config, error) {
+	return config.Load(krb5ConfigPath())
+}
+
+func NewClient(ctx context.Context, host string, port int, opts ...Option) (*Client, error) {
+	c := &Client{
+		Retry: DefaultRetryPolicy(),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	ccachePath = strings.TrimPrefix(ccachePath, "FILE:")
-	return credentials.LoadCCache(ccachePath)
-}
+	var base *url.URL
+	if c.endpoint != "" {
+		var err error
+		if base, err = parseEndpoint(c.endpoint); err != nil {
+			return nil, err
+		}
+	} else {
+		if port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("invalid port: %q", port)
+		}
+		base = hostPortURL(host, port)
+	}
 
-func NewClient(host string, port int) (*Client, error) {
-	if port <= 0 || port > 65535 {
-		return nil, fmt.Errorf("invalid port: %q", port)
+	port, err := endpointPort(base)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.tlsOptions.isZero() {
+		transport, err := c.tlsOptions.transport()
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+		}
+		c.transport = transport
 	}
 
 	krbConf, err := loadKrb5Config()
@@ -53,55 +117,161 @@ func NewClient(host string, port int) (*Client, error) {
 		return nil, fmt.Errorf("failed to load krb5.conf: %w", err)
 	}
 
-	ccache, err := loadCCache()
+	c.auth = newKerberosAuth(krbConf, c.principal, c.keytabPath, c.password)
+	krbClient, _, err := c.auth.ensure(false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load credential cache: %w", err)
+		return nil, err
 	}
 
-	krbClient, err := client.NewFromCCache(ccache, krbConf)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create kerberos client: %w", err)
+	fqdn := base.Hostname()
+	if !c.skipCanonicalization {
+		if c.resolver == nil {
+			c.resolver = net.DefaultResolver
+		}
+		fqdn, err = resolveFQDN(ctx, c.resolver, base.Hostname())
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve fqdn for host %q: %w", base.Hostname(), err)
+		}
+		if base.Port() != "" {
+			base.Host = net.JoinHostPort(fqdn, base.Port())
+		} else {
+			base.Host = fqdn
+		}
 	}
 
-	fqdn, err := resolveFQDN(host)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve fqdn for host %q: %w", host, err)
-	}
+	c.BaseURL = base
+	c.Host = fqdn
+	c.Port = port
+	c.HTTPClient = c.newSPNEGOClient(krbClient)
 
-	httpClient := spnego.NewClient(krbClient, nil, "")
+	return c, nil
+}
 
-	return &Client{
-		Host:       fqdn,
-		Port:       port,
-		HTTPClient: httpClient,
-	}, nil
+func (c *Client) newSPNEGOClient(krbClient *client.Client) *spnego.Client {
+	return spnego.NewClient(krbClient, &http.Client{Transport: c.transport}, c.spn)
 }
 
-func resolveFQDN(host string) (string, error) {
-	ips, err := net.LookupIP(host)
-	if err != nil {
-		return "", fmt.Errorf("failed to resolve IP for hostname %s: %w", host, err)
+func (c *Client) httpClient() *spnego.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.HTTPClient
+}
+
+// Resolver performs the DNS lookups used to canonicalise the roger host. *net.Resolver implements it.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// WithResolver sets the resolver used to canonicalise the roger host.
+func WithResolver(resolver Resolver) Option {
+	return func(c *Client) {
+		c.resolver = resolver
 	}
+}
 
-	for _, ip := range ips {
-		if ip.To4() == nil {
-			continue
+// WithHostCanonicalization enables or disables the forward and reverse DNS lookup that turns
+// the roger host into the canonical name its Kerberos service principal is issued for. Enabled by default.
+func WithHostCanonicalization(enabled bool) Option {
+	return func(c *Client) {
+		c.skipCanonicalization = !enabled
+	}
+}
+
+// WithServicePrincipal sets the Kerberos service principal of the roger server, e.g. "HTTP/woger.cern.ch",
+// instead of deriving it from the host. A name without service is prefixed with "HTTP/".
+func WithServicePrincipal(spn string) Option {
+	return func(c *Client) {
+		if spn != "" && !strings.Contains(spn, "/") {
+			spn = "HTTP/" + spn
+		}
+		c.spn = spn
+	}
+}
+
+// resolveFQDN returns the name of the first PTR record found for an address of host,
+// trying IPv4 addresses before IPv6 ones.
+func resolveFQDN(ctx context.Context, resolver Resolver, host string) (string, error) {
+	var addrs []net.IPAddr
+	if ip := net.ParseIP(host); ip != nil {
+		addrs = []net.IPAddr{{IP: ip}}
+	} else {
+		var err error
+		addrs, err = resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve IP for hostname %s: %w", host, err)
 		}
-		ptrs, err := net.LookupAddr(ip.String())
+	}
+
+	sort.SliceStable(addrs, func(i, j int) bool {
+		return addrs[i].IP.To4() != nil && addrs[j].IP.To4() == nil
+	})
+
+	var lastErr error
+	for _, addr := range addrs {
+		ptrs, err := resolver.LookupAddr(ctx, addr.IP.String())
 		if err != nil {
-			return "", fmt.Errorf("reverse lookup failed for IP %s: %w", ip, err)
+			lastErr = fmt.Errorf("reverse lookup failed for IP %s: %w", addr.IP, err)
+			continue
 		}
 		if len(ptrs) > 0 {
 			return strings.TrimSuffix(ptrs[0], "."), nil
 		}
 	}
-	return "", fmt.Errorf("no valid IPv4 PTR record found for host %s", host)
+	if lastErr != nil {
+		return "", lastErr
+	}
+	return "", fmt.Errorf("no PTR record found for any address of host %s", host)
+}
+
+func (c *Client) doRequest(ctx context.Context, method, url string, payload []byte) ([]byte, int, error) {
+	ctx = c.logContext(ctx)
+	policy := c.Retry.withDefaults()
+
+	reauthenticated := false
+	for attempt := 1; ; attempt++ {
+		if err := c.ensureTicket(ctx, false); err != nil {
+			return nil, 0, err
+		}
+
+		body, status, header, err := c.doOnce(ctx, method, url, payload)
+		if IsUnauthorized(err) && c.auth != nil && !reauthenticated {
+			// The ticket may have expired or been revoked, retry once with a fresh one.
+			reauthenticated = true
+			c.logger().Debug(ctx, "roger API request unauthorized, acquiring new Kerberos ticket", map[string]any{
+				"method": method,
+				"url":    url,
+			})
+			if terr := c.ensureTicket(ctx, true); terr != nil {
+				return body, status, fmt.Errorf("%w (%v)", err, terr)
+			}
+			attempt--
+			continue
+		}
+		if err == nil || attempt >= policy.MaxAttempts || !shouldRetry(ctx, method, err) {
+			return body, status, err
+		}
+
+		wait := policy.backoff(attempt, header)
+		c.logger().Debug(ctx, "Retrying roger API request", map[string]any{
+			"method":  method,
+			"url":     url,
+			"attempt": attempt,
+			"wait":    wait.String(),
+			"error":   err.Error(),
+		})
+		select {
+		case <-ctx.Done():
+			return body, status, err
+		case <-time.After(wait):
+		}
+	}
 }
 
-func (c *Client) doRequest(method, url string, payload []byte) ([]byte, int, error) {
-	req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+func (c *Client) doOnce(ctx context.Context, method, url string, payload []byte) ([]byte, int, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if payload != nil {
@@ -109,22 +279,51 @@ func (c *Client) doRequest(method, url string, payload []byte) ([]byte, int, err
 	}
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.HTTPClient.Do(req)
+	c.logger().Trace(ctx, "Sending roger API request", map[string]any{
+		"method":  method,
+		"url":     url,
+		"headers": c.redactHeaders(req.Header),
+		"body":    c.redactBody(payload),
+	})
+
+	start := time.Now()
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
-		return nil, 0, fmt.Errorf("request failed: %w", err)
+		if resp != nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusUnauthorized {
+				// SPNEGO failed to obtain a service ticket, typically because the TGT expired.
+				apiErr := newAPIError(method, url, resp.StatusCode, nil)
+				apiErr.Message = "SPNEGO authentication failed: " + err.Error()
+				return nil, resp.StatusCode, resp.Header, apiErr
+			}
+		}
+		return nil, 0, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
-			fmt.Printf("warning: failed to close response body: %v\n", cerr)
+			c.logger().Warn(ctx, "Failed to close roger API response body", map[string]any{"error": cerr.Error()})
 		}
 	}()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
+		return nil, resp.StatusCode, resp.Header, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	fmt.Printf("DEBUG: %s %s → %d %q\n", method, url, resp.StatusCode, string(body))
+	c.logger().Trace(ctx, "Received roger API response", map[string]any{
+		"method":          method,
+		"url":             url,
+		"status":          resp.StatusCode,
+		"duration":        time.Since(start).String(),
+		"request_headers": c.redactHeaders(req.Header),
+		"headers":         c.redactHeaders(resp.Header),
+		"body":            c.redactBody(body),
+	})
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return body, resp.StatusCode, resp.Header, newAPIError(method, url, resp.StatusCode, body)
+	}
 
-	return body, resp.StatusCode, nil
+	return body, resp.StatusCode, resp.Header, nil
 }
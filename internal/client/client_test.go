@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	roger "roger/internal/client"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) *roger.Client {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	host, portStr, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+
+	return &roger.Client{
+		HTTPClient: spnego.NewClient(nil, srv.Client(), ""),
+		Host:       host,
+		Port:       port,
+	}
+}
+
+func TestRequestHonoursContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(func() { close(release) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := cli.GetState(ctx, "host.cern.ch")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// fakeLogin returns Kerberos clients that are never used to log in, expiring after the given lifetimes in turn.
+type fakeLogin struct {
+	lifetimes []time.Duration
+	clients   []*client.Client
+}
+
+func (l *fakeLogin) login() (*client.Client, time.Time, error) {
+	lifetime := l.lifetimes[min(len(l.clients), len(l.lifetimes)-1)]
+	krbClient := client.NewWithPassword("user", "CERN.CH", "secret", config.New())
+	l.clients = append(l.clients, krbClient)
+	return krbClient, time.Now().Add(lifetime), nil
+}
+
+func stateHandler(calls *atomic.Int32, unauthorized int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= unauthorized {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hostname": "host.cern.ch", "appstate": "production"}`))
+	})
+}
+
+func TestTicketRenewedBeforeExpiry(t *testing.T) {
+	var calls atomic.Int32
+	cli := newTestClient(t, stateHandler(&calls, 0))
+	login := &fakeLogin{lifetimes: []time.Duration{2 * time.Minute, time.Hour}}
+	roger.SetLogin(cli, login.login)
+
+	for range 3 {
+		_, err := cli.GetState(context.Background(), "host.cern.ch")
+		require.NoError(t, err)
+	}
+
+	// The first ticket expires within the renewal margin and is replaced before the second request.
+	require.Len(t, login.clients, 2)
+	require.Empty(t, login.clients[0].Credentials.UserName(), "replaced client must be destroyed")
+	require.Equal(t, "user", login.clients[1].Credentials.UserName())
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestUnauthorizedRetriedOnceWithNewTicket(t *testing.T) {
+	var calls atomic.Int32
+	cli := newTestClient(t, stateHandler(&calls, 1))
+	login := &fakeLogin{lifetimes: []time.Duration{time.Hour}}
+	roger.SetLogin(cli, login.login)
+
+	_, err := cli.GetState(context.Background(), "host.cern.ch")
+	require.NoError(t, err)
+	require.Len(t, login.clients, 2)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestUnauthorizedNotRetriedTwice(t *testing.T) {
+	var calls atomic.Int32
+	cli := newTestClient(t, stateHandler(&calls, 10))
+	login := &fakeLogin{lifetimes: []time.Duration{time.Hour}}
+	roger.SetLogin(cli, login.login)
+
+	_, err := cli.GetState(context.Background(), "host.cern.ch")
+	require.True(t, roger.IsUnauthorized(err))
+	require.Len(t, login.clients, 2)
+	require.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultAPIPath is the path of the roger API used when only host and port are given.
+const defaultAPIPath = "/roger/v1/"
+
+// WithEndpoint sets the full base URL of the roger API, e.g. "https://woger-direct.cern.ch:8201/roger/v1/",
+// taking precedence over the host and port passed to NewClient.
+func WithEndpoint(endpoint string) Option {
+	return func(c *Client) {
+		c.endpoint = endpoint
+	}
+}
+
+func parseEndpoint(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme must be http or https", raw)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: missing host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid endpoint %q: query and fragment are not allowed", raw)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u, nil
+}
+
+func hostPortURL(host string, port int) *url.URL {
+	return &url.URL{
+		Scheme: "https",
+		Host:   net.JoinHostPort(host, strconv.Itoa(port)),
+		Path:   defaultAPIPath,
+	}
+}
+
+// endpointPort returns the port of u, defaulting to the port of its scheme.
+func endpointPort(u *url.URL) (int, error) {
+	if p := u.Port(); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil || port <= 0 || port > 65535 {
+			return 0, fmt.Errorf("invalid port: %q", p)
+		}
+		return port, nil
+	}
+	if u.Scheme == "http" {
+		return 80, nil
+	}
+	return 443, nil
+}
+
+func (c *Client) baseURL() *url.URL {
+	if c.BaseURL != nil {
+		return c.BaseURL
+	}
+	return hostPortURL(c.Host, c.Port)
+}
+
+// endpointURL builds the URL of a roger API resource. Roger expects a trailing slash on every resource.
+func (c *Client) endpointURL(elem ...string) string {
+	u := c.baseURL().JoinPath(elem...)
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u.String()
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	roger "roger/internal/client"
+
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseURLWithPathPrefix(t *testing.T) {
+	var requested string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hostname": "host.cern.ch", "appstate": "production"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	base, err := url.Parse(srv.URL + "/proxy/roger/v2/")
+	require.NoError(t, err)
+
+	cli := &roger.Client{
+		HTTPClient: spnego.NewClient(nil, srv.Client(), ""),
+		BaseURL:    base,
+	}
+
+	_, err = cli.GetState(context.Background(), "host.cern.ch")
+	require.NoError(t, err)
+	require.Equal(t, "/proxy/roger/v2/state/host.cern.ch/", requested)
+}
+
+func TestNewClientWithInvalidEndpoint(t *testing.T) {
+	_, err := roger.NewClient(context.Background(), "", 0, roger.WithEndpoint("ftp://roger.cern.ch/"))
+	require.ErrorContains(t, err, "scheme must be http or https")
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned for any response of the roger API that signals a failure.
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	// Message is the error reported by the server, if the body could be parsed.
+	Message string
+	// Body is the raw response body.
+	Body string
+}
+
+func newAPIError(method, url string, status int, body []byte) *APIError {
+	return &APIError{
+		StatusCode: status,
+		Method:     method,
+		URL:        url,
+		Message:    parseErrorMessage(body),
+		Body:       string(body),
+	}
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = strings.TrimSpace(e.Body)
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: status=%d: %s", e.Method, e.URL, e.StatusCode, msg)
+}
+
+// parseErrorMessage extracts the error message from the JSON error body returned by roger.
+func parseErrorMessage(body []byte) string {
+	var parsed map[string]any
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return ""
+	}
+	for _, key := range []string{"detail", "message", "error"} {
+		if v, ok := parsed[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func hasStatus(err error, statuses ...int) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, s := range statuses {
+		if apiErr.StatusCode == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports whether err is an APIError for a missing roger entry.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is an APIError caused by failed authentication.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is an APIError caused by missing permissions.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsConflict reports whether err is an APIError caused by a conflicting roger entry.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsServerError reports whether err is an APIError with a 5xx status.
+func IsServerError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 500
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	roger "roger/internal/client"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIErrorClasses(t *testing.T) {
+	tests := []struct {
+		status int
+		check  func(error) bool
+	}{
+		{http.StatusNotFound, roger.IsNotFound},
+		{http.StatusUnauthorized, roger.IsUnauthorized},
+		{http.StatusForbidden, roger.IsForbidden},
+		{http.StatusConflict, roger.IsConflict},
+		{http.StatusServiceUnavailable, roger.IsServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"detail": "something went wrong"}`))
+			}))
+
+			_, err := cli.GetState(context.Background(), "host.cern.ch")
+			require.Error(t, err)
+			require.True(t, tt.check(err))
+
+			var apiErr *roger.APIError
+			require.True(t, errors.As(err, &apiErr))
+			require.Equal(t, tt.status, apiErr.StatusCode)
+			require.Equal(t, http.MethodGet, apiErr.Method)
+			require.Equal(t, "something went wrong", apiErr.Message)
+		})
+	}
+}
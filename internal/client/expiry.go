@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Expiry is the point in time at which roger reverts a state. It is sent to roger as a
+// Unix timestamp, the zero value clears the expiry.
+type Expiry time.Time
+
+func (e Expiry) MarshalJSON() ([]byte, error) {
+	t := time.Time(e)
+	if t.IsZero() {
+		return json.Marshal("")
+	}
+	return json.Marshal(strconv.FormatInt(t.Unix(), 10))
+}
+
+// ParseExpiry accepts an absolute RFC3339 timestamp or a duration such as "4h", which is
+// taken relative to now.
+func ParseExpiry(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expiry %q is neither an RFC3339 timestamp nor a duration", value)
+	}
+	if d <= 0 {
+		return time.Time{}, fmt.Errorf("expiry duration %q must be positive", value)
+	}
+	return now.Add(d), nil
+}
+
+// ExpiresAt returns the expiry reported by roger, or false if the state does not expire.
+func (s *State) ExpiresAt() (time.Time, bool) {
+	return parseTime(s.Expires, s.ExpiresDT)
+}
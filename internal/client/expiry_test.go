@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	roger "roger/internal/client"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	expires, err := roger.ParseExpiry("4h", now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(4*time.Hour), expires)
+
+	expires, err = roger.ParseExpiry("2025-03-02T08:00:00+01:00", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 3, 2, 7, 0, 0, 0, time.UTC), expires.UTC())
+
+	_, err = roger.ParseExpiry("-1h", now)
+	require.ErrorContains(t, err, "must be positive")
+
+	_, err = roger.ParseExpiry("tomorrow", now)
+	require.ErrorContains(t, err, "neither an RFC3339 timestamp nor a duration")
+}
+
+func TestStateInputSendsExpiryAsUnixTimestamp(t *testing.T) {
+	expires := roger.Expiry(time.Unix(1740830400, 0))
+	payload, err := json.Marshal(roger.StateInput{Hostname: "host.cern.ch", Expires: &expires})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"hostname": "host.cern.ch", "expires": "1740830400"}`, string(payload))
+
+	payload, err = json.Marshal(roger.StateInput{Hostname: "host.cern.ch", Expires: roger.Ptr(roger.Expiry{})})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"hostname": "host.cern.ch", "expires": ""}`, string(payload))
+}
+
+func TestStateExpiresAt(t *testing.T) {
+	expires, ok := (&roger.State{Expires: "1740830400"}).ExpiresAt()
+	require.True(t, ok)
+	require.Equal(t, time.Unix(1740830400, 0).UTC(), expires)
+
+	expires, ok = (&roger.State{ExpiresDT: "2025-03-01 12:00:00"}).ExpiresAt()
+	require.True(t, ok)
+	require.Equal(t, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), expires)
+
+	_, ok = (&roger.State{Expires: "0"}).ExpiresAt()
+	require.False(t, ok)
+
+	_, ok = (&roger.State{}).ExpiresAt()
+	require.False(t, ok)
+}
@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+)
+
+// SetLogin replaces the Kerberos login of c. The HTTP clients created after a login keep using
+// the transport of the current one, e.g. the one trusting an httptest server.
+func SetLogin(c *Client, login func() (*client.Client, time.Time, error)) {
+	c.auth = &kerberosAuth{login: login}
+	c.transport = c.HTTPClient.Client.Transport
+}
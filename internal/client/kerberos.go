@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/types"
+)
+
+// WithKeytab makes the client log in as principal using the keys of the given keytab
+// instead of reading the credential cache. A principal without realm uses the
+// default realm of krb5.conf.
+func WithKeytab(principal, keytabPath string) Option {
+	return func(c *Client) {
+		c.principal = principal
+		c.keytabPath = keytabPath
+	}
+}
+
+// WithPassword makes the client log in as principal with the given password
+// instead of reading the credential cache.
+func WithPassword(principal, password string) Option {
+	return func(c *Client) {
+		c.principal = principal
+		c.password = password
+	}
+}
+
+// renewBefore is how long before the expiry of the ticket a new one is acquired.
+const renewBefore = 5 * time.Minute
+
+// kerberosAuth owns the Kerberos client of a Client and re-acquires its TGT before it expires.
+type kerberosAuth struct {
+	// login acquires a new TGT and returns the time until which it can be used.
+	login func() (*client.Client, time.Time, error)
+
+	mu      sync.Mutex
+	client  *client.Client
+	expires time.Time
+}
+
+// newKerberosAuth logs in with the keytab or the password if given, and with the credential cache otherwise.
+func newKerberosAuth(conf *config.Config, principal, keytabPath, password string) *kerberosAuth {
+	return &kerberosAuth{
+		login: func() (*client.Client, time.Time, error) {
+			switch {
+			case keytabPath != "":
+				return withSessionEndTime(loginWithKeytab(principal, keytabPath, conf))
+			case password != "":
+				return withSessionEndTime(loginWithPassword(principal, password, conf))
+			default:
+				return loginWithCCache(conf)
+			}
+		},
+	}
+}
+
+// ensure returns the current Kerberos client, logging in again first if the ticket is about to
+// expire or force is set. The boolean reports whether a new client was created.
+func (a *kerberosAuth) ensure(force bool) (*client.Client, bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.client != nil && !force {
+		if time.Until(a.expires) > renewBefore {
+			return a.client, false, nil
+		}
+		// gokrb5 renews renewable tickets on demand, keep the client if it already did.
+		if expires, err := sessionEndTime(a.client); err == nil && time.Until(expires) > renewBefore {
+			a.expires = expires
+			return a.client, false, nil
+		}
+	}
+
+	krbClient, expires, err := a.login()
+	if err != nil {
+		return nil, false, err
+	}
+	if a.client != nil {
+		// Stops the automatic renewal of the sessions of the replaced client.
+		a.client.Destroy()
+	}
+	a.client = krbClient
+	a.expires = expires
+	return krbClient, true, nil
+}
+
+// withSessionEndTime adds the end time of the TGT issued by the KDC to the result of a login.
+func withSessionEndTime(krbClient *client.Client, err error) (*client.Client, time.Time, error) {
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	expires, err := sessionEndTime(krbClient)
+	if err != nil {
+		krbClient.Destroy()
+		return nil, time.Time{}, err
+	}
+	return krbClient, expires, nil
+}
+
+// sessionEndTime returns the end time of the TGT session of the realm of the client, which the KDC
+// may have capped below the ticket_lifetime of krb5.conf. gokrb5 only exposes its sessions through
+// Print, so the "TGT Sessions" section of that output is decoded.
+func sessionEndTime(krbClient *client.Client) (time.Time, error) {
+	realm := krbClient.Credentials.Domain()
+
+	var out bytes.Buffer
+	krbClient.Print(&out)
+	_, section, _ := strings.Cut(out.String(), "TGT Sessions:\n")
+	section, _, _ = strings.Cut(section, "\nService ticket cache:")
+
+	var sessions []struct {
+		Realm   string
+		EndTime time.Time
+	}
+	if err := json.Unmarshal([]byte(section), &sessions); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read kerberos sessions: %w", err)
+	}
+	for _, s := range sessions {
+		if s.Realm == realm {
+			return s.EndTime, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no TGT session for realm %s", realm)
+}
+
+// loginWithCCache (re-)reads the credential cache, which may have been renewed externally,
+// e.g. by kinit or k5start. Renewable tickets are renewed by gokrb5 on demand until their
+// renew-till time, but only while they have not expired.
+func loginWithCCache(krbConf *config.Config) (*client.Client, time.Time, error) {
+	ccache, err := loadCCache()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to load credential cache: %w", err)
+	}
+
+	tgt, ok := ccache.GetEntry(types.NewPrincipalName(nametype.KRB_NT_SRV_INST, "krbtgt/"+ccache.GetClientRealm()))
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("no TGT for realm %s in credential cache", ccache.GetClientRealm())
+	}
+	if time.Now().After(tgt.EndTime) {
+		return nil, time.Time{}, fmt.Errorf("kerberos ticket of %s in credential cache expired at %s, renew it with kinit",
+			ccache.GetClientPrincipalName().PrincipalNameString(), tgt.EndTime.Format(time.RFC3339))
+	}
+
+	krbClient, err := client.NewFromCCache(ccache, krbConf)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to create kerberos client: %w", err)
+	}
+	return krbClient, tgt.EndTime, nil
+}
+
+// ensureTicket makes sure the client uses a valid ticket, swapping the HTTP client if a new one was acquired.
+func (c *Client) ensureTicket(ctx context.Context, force bool) error {
+	if c.auth == nil {
+		return nil
+	}
+
+	krbClient, renewed, err := c.auth.ensure(force)
+	if err != nil {
+		return fmt.Errorf("failed to acquire kerberos ticket: %w", err)
+	}
+	if !renewed {
+		return nil
+	}
+
+	c.mu.Lock()
+	c.HTTPClient = c.newSPNEGOClient(krbClient)
+	c.mu.Unlock()
+
+	c.logger().Debug(ctx, "Acquired new Kerberos ticket", map[string]any{"forced": force})
+	return nil
+}
+
+func loginWithKeytab(principal, keytabPath string, krbConf *config.Config) (*client.Client, error) {
+	username, realm, err := splitPrincipal(principal, krbConf)
+	if err != nil {
+		return nil, err
+	}
+
+	kt, err := keytab.Load(keytabPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keytab %q: %w", keytabPath, err)
+	}
+
+	krbClient := client.NewWithKeytab(username, realm, kt, krbConf)
+	if err := krbClient.Login(); err != nil {
+		return nil, fmt.Errorf("failed to log in as %s@%s with keytab %q: %w", username, realm, keytabPath, explainLoginError(err, realm))
+	}
+	return krbClient, nil
+}
+
+func loginWithPassword(principal, password string, krbConf *config.Config) (*client.Client, error) {
+	username, realm, err := splitPrincipal(principal, krbConf)
+	if err != nil {
+		return nil, err
+	}
+
+	krbClient := client.NewWithPassword(username, realm, password, krbConf)
+	if err := krbClient.Login(); err != nil {
+		return nil, fmt.Errorf("failed to log in as %s@%s with password: %w", username, realm, explainLoginError(err, realm))
+	}
+	return krbClient, nil
+}
+
+// loginError adds a human readable hint to the errors gokrb5 returns for common login failures.
+type loginError struct {
+	hint string
+	err  error
+}
+
+func (e *loginError) Error() string {
+	return e.hint + ": " + e.err.Error()
+}
+
+func (e *loginError) Unwrap() error {
+	return e.err
+}
+
+// explainLoginError maps the KDC error codes embedded in gokrb5 errors to a hint.
+// gokrb5 flattens KDC errors into strings, so matching on the message is the only option.
+func explainLoginError(err error, realm string) error {
+	msg := err.Error()
+	var hint string
+	switch {
+	case strings.Contains(msg, "KDC_ERR_PREAUTH_FAILED"),
+		strings.Contains(msg, "client password/keytab incorrect"):
+		hint = "pre-authentication failed, the password or keytab does not match the principal"
+	case strings.Contains(msg, "KRB_AP_ERR_SKEW"):
+		hint = "the clock of this machine differs too much from the KDC, check that time is synchronised"
+	case strings.Contains(msg, "KDC_ERR_C_PRINCIPAL_UNKNOWN"):
+		hint = fmt.Sprintf("the principal is unknown in realm %s, check the principal and its realm", realm)
+	case strings.Contains(msg, "KDC_ERR_WRONG_REALM"),
+		strings.Contains(msg, "no KDCs defined in configuration for realm"):
+		hint = fmt.Sprintf("realm %s is wrong or not configured in krb5.conf, realms are case sensitive", realm)
+	default:
+		return err
+	}
+	return &loginError{hint: hint, err: err}
+}
+
+// splitPrincipal splits "user@REALM" into its parts, falling back to the default realm of krb5.conf.
+func splitPrincipal(principal string, krbConf *config.Config) (string, string, error) {
+	if principal == "" {
+		return "", "", fmt.Errorf("kerberos principal must not be empty")
+	}
+
+	username, realm, found := strings.Cut(principal, "@")
+	if !found || realm == "" {
+		realm = krbConf.LibDefaults.DefaultRealm
+	}
+	if username == "" {
+		return "", "", fmt.Errorf("invalid kerberos principal %q", principal)
+	}
+	if realm == "" {
+		return "", "", fmt.Errorf("kerberos principal %q has no realm and krb5.conf defines no default_realm", principal)
+	}
+	return username, realm, nil
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	roger "roger/internal/client"
+
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/types"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKrb5Conf(t *testing.T) {
+	t.Helper()
+
+	conf := `[libdefaults]
+  default_realm = EXAMPLE.ORG
+
+[realms]
+  EXAMPLE.ORG = {
+    kdc = 127.0.0.1:1
+  }
+`
+	path := filepath.Join(t.TempDir(), "krb5.conf")
+	require.NoError(t, os.WriteFile(path, []byte(conf), 0o600))
+	t.Setenv("KRB5_CONFIG", path)
+}
+
+func TestNewClientWithMissingKeytab(t *testing.T) {
+	writeKrb5Conf(t)
+
+	keytab := filepath.Join(t.TempDir(), "missing.keytab")
+	_, err := roger.NewClient(context.Background(), "localhost", 8201, roger.WithKeytab("svc", keytab))
+	require.ErrorContains(t, err, "failed to load keytab")
+}
+
+func TestNewClientWithPasswordForUnknownRealm(t *testing.T) {
+	writeKrb5Conf(t)
+
+	_, err := roger.NewClient(context.Background(), "localhost", 8201, roger.WithPassword("svc@OTHER.ORG", "secret"))
+	require.ErrorContains(t, err, "realm OTHER.ORG is wrong or not configured in krb5.conf")
+}
+
+// writeCCache writes a version 4 credential cache holding a TGT of user@EXAMPLE.ORG with the given
+// end and renew-till times, and points KRB5CCNAME to it.
+func writeCCache(t *testing.T, endTime, renewTill time.Time) {
+	t.Helper()
+
+	tgt := types.NewPrincipalName(nametype.KRB_NT_SRV_INST, "krbtgt/EXAMPLE.ORG")
+	tkt := messages.Ticket{
+		TktVNO:  5,
+		Realm:   "EXAMPLE.ORG",
+		SName:   tgt,
+		EncPart: types.EncryptedData{EType: 18, Cipher: []byte("cipher")},
+	}
+	ticket, err := tkt.Marshal()
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	write := func(v any) { require.NoError(t, binary.Write(&b, binary.BigEndian, v)) }
+	data := func(d []byte) {
+		write(uint32(len(d)))
+		b.Write(d)
+	}
+	principal := func(name types.PrincipalName) {
+		write(uint32(name.NameType))
+		write(uint32(len(name.NameString)))
+		data([]byte("EXAMPLE.ORG"))
+		for _, component := range name.NameString {
+			data([]byte(component))
+		}
+	}
+	user := types.NewPrincipalName(nametype.KRB_NT_PRINCIPAL, "user")
+
+	b.Write([]byte{5, 4, 0, 0})
+	principal(user)
+	principal(user)
+	principal(tgt)
+	write(uint16(18))
+	data(make([]byte, 32))
+	for _, ts := range []time.Time{endTime.Add(-10 * time.Hour), endTime.Add(-10 * time.Hour), endTime, renewTill} {
+		write(uint32(ts.Unix()))
+	}
+	write(uint8(0))
+	write(uint32(0))
+	write(uint32(0))
+	write(uint32(0))
+	data(ticket)
+	data(nil)
+
+	path := filepath.Join(t.TempDir(), "krb5cc")
+	require.NoError(t, os.WriteFile(path, b.Bytes(), 0o600))
+	t.Setenv("KRB5CCNAME", "FILE:"+path)
+}
+
+func TestExpiredCCacheTicketRejectedWhileRenewable(t *testing.T) {
+	writeKrb5Conf(t)
+	endTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeCCache(t, endTime, time.Now().Add(7*24*time.Hour))
+
+	_, err := roger.NewClient(context.Background(), "localhost", 8201, roger.WithHostCanonicalization(false))
+	require.ErrorContains(t, err, "expired at "+endTime.Format(time.RFC3339)+", renew it with kinit")
+}
+
+func TestValidCCacheTicketAccepted(t *testing.T) {
+	writeKrb5Conf(t)
+	writeCCache(t, time.Now().Add(time.Hour), time.Now().Add(7*24*time.Hour))
+
+	_, err := roger.NewClient(context.Background(), "localhost", 8201, roger.WithHostCanonicalization(false))
+	require.NoError(t, err)
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxListPages guards ListStates against servers returning the same next page forever.
+const maxListPages = 1000
+
+// StateFilter restricts the states returned by ListStates. Fields left empty or nil do not filter.
+type StateFilter struct {
+	AppState string
+	// Hostname is a shell pattern such as "batch*.cern.ch", see path.Match.
+	Hostname   string
+	AppAlarmed *bool
+	HWAlarmed  *bool
+	NCAlarmed  *bool
+	OSAlarmed  *bool
+}
+
+// query returns the filters passed to the server. Hostname patterns are only sent if they are
+// a plain hostname, as the server matches them exactly.
+func (f StateFilter) query() url.Values {
+	q := url.Values{}
+	if f.AppState != "" {
+		q.Set("appstate", f.AppState)
+	}
+	if f.Hostname != "" && !strings.ContainsAny(f.Hostname, `*?[\`) {
+		q.Set("hostname", f.Hostname)
+	}
+	for key, v := range map[string]*bool{
+		"app_alarmed": f.AppAlarmed,
+		"hw_alarmed":  f.HWAlarmed,
+		"nc_alarmed":  f.NCAlarmed,
+		"os_alarmed":  f.OSAlarmed,
+	} {
+		if v != nil {
+			q.Set(key, strconv.FormatBool(*v))
+		}
+	}
+	return q
+}
+
+// Match reports whether state passes the filter.
+func (f StateFilter) Match(state *State) bool {
+	if f.AppState != "" && state.AppState != f.AppState {
+		return false
+	}
+	if f.Hostname != "" {
+		if ok, _ := path.Match(f.Hostname, state.Hostname); !ok {
+			return false
+		}
+	}
+	for _, flag := range []struct {
+		want *bool
+		got  bool
+	}{
+		{f.AppAlarmed, state.AppAlarmed},
+		{f.HWAlarmed, state.HWAlarmed},
+		{f.NCAlarmed, state.NCAlarmed},
+		{f.OSAlarmed, state.OSAlarmed},
+	} {
+		if flag.want != nil && *flag.want != flag.got {
+			return false
+		}
+	}
+	return true
+}
+
+// statePage is a page of a paginated state listing.
+type statePage struct {
+	Results []State `json:"results"`
+	Next    *string `json:"next"`
+}
+
+// ListStates returns the states matching filter. The filters are passed to the server and
+// applied again to the result, for servers that do not support some of them. Paginated
+// responses are followed until the last page.
+func (c *Client) ListStates(ctx context.Context, filter StateFilter) ([]State, error) {
+	if _, err := path.Match(filter.Hostname, ""); err != nil {
+		return nil, fmt.Errorf("invalid hostname pattern %q: %w", filter.Hostname, err)
+	}
+
+	next := c.endpointURL("state")
+	if q := filter.query(); len(q) > 0 {
+		next += "?" + q.Encode()
+	}
+
+	var states []State
+	for page := 0; next != ""; page++ {
+		if page == maxListPages {
+			return nil, fmt.Errorf("listing states exceeded %d pages", maxListPages)
+		}
+
+		body, _, err := c.doRequest(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		results, nextURL, err := c.parseStatePage(body, next)
+		if err != nil {
+			return nil, err
+		}
+		for i := range results {
+			if filter.Match(&results[i]) {
+				states = append(states, results[i])
+			}
+		}
+		next = nextURL
+	}
+	return states, nil
+}
+
+// parseStatePage accepts a plain list of states or a page with results and a link to the next page,
+// which is resolved relative to the URL of the current page. Links to another scheme or host are
+// rejected, as the Kerberos credentials would be sent along.
+func (c *Client) parseStatePage(body []byte, current string) ([]State, string, error) {
+	var list []State
+	if err := json.Unmarshal(body, &list); err == nil {
+		return list, "", nil
+	}
+
+	var page statePage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if page.Next == nil || *page.Next == "" {
+		return page.Results, "", nil
+	}
+
+	base, err := url.Parse(current)
+	if err != nil {
+		return nil, "", err
+	}
+	next, err := base.Parse(*page.Next)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid next page %q: %w", *page.Next, err)
+	}
+	if endpoint := c.baseURL(); next.Scheme != endpoint.Scheme || !strings.EqualFold(next.Host, endpoint.Host) {
+		return nil, "", fmt.Errorf("next page %q is not on the roger endpoint %s://%s", *page.Next, endpoint.Scheme, endpoint.Host)
+	}
+	return page.Results, next.String(), nil
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	roger "roger/internal/client"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListStatesFollowsPages(t *testing.T) {
+	cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "draining", r.URL.Query().Get("appstate"))
+		require.Empty(t, r.URL.Query().Get("hostname"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			_, _ = w.Write([]byte(`{"results": [
+				{"hostname": "batch1.cern.ch", "appstate": "draining"},
+				{"hostname": "web1.cern.ch", "appstate": "draining"}
+			], "next": "?appstate=draining&page=2"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results": [
+			{"hostname": "batch2.cern.ch", "appstate": "draining"},
+			{"hostname": "batch3.cern.ch", "appstate": "production"}
+		], "next": null}`))
+	}))
+
+	states, err := cli.ListStates(context.Background(), roger.StateFilter{AppState: "draining", Hostname: "batch*.cern.ch"})
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+	require.Equal(t, "batch1.cern.ch", states[0].Hostname)
+	require.Equal(t, "batch2.cern.ch", states[1].Hostname)
+}
+
+func TestListStatesFiltersPlainList(t *testing.T) {
+	cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "false", r.URL.Query().Get("hw_alarmed"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"hostname": "batch1.cern.ch", "appstate": "production", "hw_alarmed": true},
+			{"hostname": "batch2.cern.ch", "appstate": "production", "hw_alarmed": false}
+		]`))
+	}))
+
+	states, err := cli.ListStates(context.Background(), roger.StateFilter{HWAlarmed: roger.Ptr(false)})
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	require.Equal(t, "batch2.cern.ch", states[0].Hostname)
+}
+
+func TestListStatesRejectsInvalidPattern(t *testing.T) {
+	cli := newTestClient(t, http.NotFoundHandler())
+
+	_, err := cli.ListStates(context.Background(), roger.StateFilter{Hostname: "batch[.cern.ch"})
+	require.ErrorContains(t, err, "invalid hostname pattern")
+}
+
+func TestListStatesRejectsNextPageOnOtherHost(t *testing.T) {
+	for _, next := range []string{"https://roger.example.org/roger/v1/state/?page=2", "//roger.example.org/state/", "http://%s/roger/v1/state/?page=2"} {
+		var calls atomic.Int32
+		cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"results": [], "next": %q}`, strings.ReplaceAll(next, "%s", r.Host))
+		}))
+
+		_, err := cli.ListStates(context.Background(), roger.StateFilter{})
+		require.ErrorContains(t, err, "is not on the roger endpoint")
+		require.EqualValues(t, 1, calls.Load())
+	}
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const maskedValue = "***"
+
+// Logger receives the diagnostic output of a Client.
+// Request and response details are only ever logged at trace level.
+type Logger interface {
+	Trace(ctx context.Context, msg string, fields map[string]any)
+	Debug(ctx context.Context, msg string, fields map[string]any)
+	Warn(ctx context.Context, msg string, fields map[string]any)
+}
+
+// ContextLogger is a Logger that prepares the context once per roger API call,
+// e.g. to set up a logging subsystem, instead of on every log message.
+type ContextLogger interface {
+	Logger
+	NewContext(ctx context.Context) context.Context
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Trace(context.Context, string, map[string]any) {}
+func (nopLogger) Debug(context.Context, string, map[string]any) {}
+func (nopLogger) Warn(context.Context, string, map[string]any)  {}
+
+// WithLogger sets the logger used by the client. By default nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.Logger = logger
+	}
+}
+
+// WithMaskedFields masks the values of the given JSON body fields and HTTP headers in logs,
+// in addition to the authentication headers that are always masked.
+func WithMaskedFields(fields ...string) Option {
+	return func(c *Client) {
+		c.MaskedFields = append(c.MaskedFields, fields...)
+	}
+}
+
+func (c *Client) logger() Logger {
+	if c.Logger == nil {
+		return nopLogger{}
+	}
+	return c.Logger
+}
+
+// logContext prepares ctx for the logger of the client. Calling it again on the returned context is cheap.
+func (c *Client) logContext(ctx context.Context) context.Context {
+	if l, ok := c.logger().(ContextLogger); ok {
+		return l.NewContext(ctx)
+	}
+	return ctx
+}
+
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"WWW-Authenticate",
+	"Cookie",
+	"Set-Cookie",
+}
+
+func (c *Client) isMasked(key string) bool {
+	for _, f := range c.MaskedFields {
+		if strings.EqualFold(f, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeaders flattens the headers for logging, masking credentials such as SPNEGO tokens.
+func (c *Client) redactHeaders(header http.Header) map[string]string {
+	redacted := make(map[string]string, len(header))
+	for key, values := range header {
+		value := strings.Join(values, ", ")
+		switch {
+		case c.isMasked(key):
+			value = maskedValue
+		case containsFold(sensitiveHeaders, key):
+			if scheme, _, ok := strings.Cut(value, " "); ok {
+				value = scheme + " " + maskedValue
+			} else {
+				value = maskedValue
+			}
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// redactBody masks the configured fields of a JSON body. Bodies that are not JSON are returned unchanged.
+func (c *Client) redactBody(body []byte) string {
+	if len(body) == 0 || len(c.MaskedFields) == 0 {
+		return string(body)
+	}
+
+	var parsed any
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return string(body)
+	}
+
+	redacted, err := json.Marshal(c.redactValue(parsed))
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func (c *Client) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if c.isMasked(key) {
+				v[key] = maskedValue
+				continue
+			}
+			v[key] = c.redactValue(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = c.redactValue(value)
+		}
+	}
+	return v
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]any
+}
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordingLogger) record(level, msg string, fields map[string]any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, fields: fields})
+}
+
+func (l *recordingLogger) Trace(_ context.Context, msg string, fields map[string]any) {
+	l.record("trace", msg, fields)
+}
+
+func (l *recordingLogger) Debug(_ context.Context, msg string, fields map[string]any) {
+	l.record("debug", msg, fields)
+}
+
+func (l *recordingLogger) Warn(_ context.Context, msg string, fields map[string]any) {
+	l.record("warn", msg, fields)
+}
+
+func TestLoggingRedactsCredentialsAndMaskedFields(t *testing.T) {
+	cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", "Negotiate c2VjcmV0")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hostname": "host.cern.ch", "appstate": "production", "message": "secret"}`))
+	}))
+	logger := &recordingLogger{}
+	cli.Logger = logger
+	cli.MaskedFields = []string{"message"}
+
+	_, err := cli.GetState(context.Background(), "host.cern.ch")
+	require.NoError(t, err)
+
+	require.Len(t, logger.entries, 2)
+	for _, entry := range logger.entries {
+		require.Equal(t, "trace", entry.level)
+	}
+
+	response := logger.entries[1].fields
+	require.Equal(t, "Negotiate ***", response["headers"].(map[string]string)["Www-Authenticate"])
+	require.NotContains(t, response["body"], "secret")
+	require.Contains(t, response["body"], `"message":"***"`)
+}
+
+type contextKey struct{}
+
+// contextLogger marks the context in NewContext and records whether log calls received it.
+type contextLogger struct {
+	recordingLogger
+	prepared int
+	unmarked int
+}
+
+func (l *contextLogger) NewContext(ctx context.Context) context.Context {
+	if ctx.Value(contextKey{}) != nil {
+		return ctx
+	}
+	l.prepared++
+	return context.WithValue(ctx, contextKey{}, true)
+}
+
+func (l *contextLogger) Trace(ctx context.Context, msg string, fields map[string]any) {
+	if ctx.Value(contextKey{}) == nil {
+		l.unmarked++
+	}
+	l.recordingLogger.Trace(ctx, msg, fields)
+}
+
+func TestContextLoggerPreparedOncePerCall(t *testing.T) {
+	cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hostname": "host.cern.ch", "appstate": "production"}`))
+	}))
+	logger := &contextLogger{}
+	cli.Logger = logger
+
+	_, err := cli.GetState(context.Background(), "host.cern.ch")
+	require.NoError(t, err)
+
+	require.Len(t, logger.entries, 2)
+	require.Equal(t, 1, logger.prepared)
+	require.Zero(t, logger.unmarked)
+}
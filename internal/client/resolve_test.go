@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver struct {
+	addrs map[string][]string
+	ptrs  map[string][]string
+}
+
+func (r fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+	for _, a := range r.addrs[host] {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(a)})
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+	return addrs, nil
+}
+
+func (r fakeResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	ptrs, ok := r.ptrs[addr]
+	if !ok {
+		return nil, fmt.Errorf("no PTR for %s", addr)
+	}
+	return ptrs, nil
+}
+
+func TestResolveFQDN(t *testing.T) {
+	resolver := fakeResolver{
+		addrs: map[string][]string{
+			"dual":      {"2001:db8::1", "192.0.2.1"},
+			"ipv6-only": {"2001:db8::2"},
+			"no-ptr":    {"192.0.2.3"},
+		},
+		ptrs: map[string][]string{
+			"192.0.2.1":   {"dual-v4.cern.ch."},
+			"2001:db8::1": {"dual-v6.cern.ch."},
+			"2001:db8::2": {"v6.cern.ch."},
+		},
+	}
+
+	tests := []struct {
+		host string
+		want string
+		err  bool
+	}{
+		{host: "dual", want: "dual-v4.cern.ch"},
+		{host: "ipv6-only", want: "v6.cern.ch"},
+		{host: "2001:db8::2", want: "v6.cern.ch"},
+		{host: "no-ptr", err: true},
+		{host: "unknown", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			got, err := resolveFQDN(context.Background(), resolver, tt.host)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts = 4
+	defaultRetryBaseBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff  = 30 * time.Second
+	defaultRetryJitter      = 0.2
+)
+
+// RetryPolicy controls how transient failures of the roger API are retried.
+// A MaxAttempts of zero or one disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry, doubled on every further attempt.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts, including waits requested via Retry-After.
+	MaxBackoff time.Duration
+	// Jitter is the fraction (0 to 1) of every wait that is randomised.
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns the policy used by clients created with NewClient.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: defaultRetryMaxAttempts,
+		BaseBackoff: defaultRetryBaseBackoff,
+		MaxBackoff:  defaultRetryMaxBackoff,
+		Jitter:      defaultRetryJitter,
+	}
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	if p.BaseBackoff <= 0 {
+		p.BaseBackoff = defaultRetryBaseBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	return p
+}
+
+// backoff returns how long to wait after the given (1-based) failed attempt.
+func (p RetryPolicy) backoff(attempt int, header http.Header) time.Duration {
+	if wait, ok := retryAfter(header); ok {
+		return min(wait, p.MaxBackoff)
+	}
+
+	wait := p.BaseBackoff
+	for i := 1; i < attempt && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, p.MaxBackoff)
+
+	if p.Jitter > 0 {
+		wait -= time.Duration(rand.Float64() * p.Jitter * float64(wait))
+	}
+	return wait
+}
+
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// shouldRetry reports whether a failed request may be sent again.
+// Requests that never reached the server are retried for every method,
+// everything else only for idempotent methods.
+func shouldRetry(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return isIdempotent(method)
+		}
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	if errors.As(err, &opErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return isIdempotent(method)
+	}
+
+	return false
+}
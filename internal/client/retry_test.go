@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	roger "roger/internal/client"
+
+	"github.com/stretchr/testify/require"
+)
+
+func flakyHandler(failures int32, calls *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hostname": "host.cern.ch", "appstate": "production"}`))
+	})
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	cli := newTestClient(t, flakyHandler(2, &calls))
+	cli.Retry = roger.RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	state, err := cli.GetState(context.Background(), "host.cern.ch")
+	require.NoError(t, err)
+	require.Equal(t, "production", state.AppState)
+	require.EqualValues(t, 3, calls.Load())
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	cli := newTestClient(t, flakyHandler(5, &calls))
+	cli.Retry = roger.RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	_, err := cli.GetState(context.Background(), "host.cern.ch")
+	require.True(t, roger.IsServerError(err))
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestRetrySkipsNonIdempotentMethods(t *testing.T) {
+	var calls atomic.Int32
+	cli := newTestClient(t, flakyHandler(1, &calls))
+	cli.Retry = roger.RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	_, err := cli.CreateState(context.Background(), roger.StateInput{Hostname: "host.cern.ch", AppState: roger.Ptr("production")})
+	require.True(t, roger.IsServerError(err))
+	require.EqualValues(t, 1, calls.Load())
+}
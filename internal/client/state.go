@@ -5,9 +5,13 @@
 package roger
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type State struct {
@@ -26,21 +30,93 @@ type State struct {
 	UpdatedByPuppet bool   `json:"updated_by_puppet"`
 }
 
-func (c *Client) CreateState(hostname, message, appstate string) (*State, error) {
-	url := fmt.Sprintf("https://%s:%d/roger/v1/state/", c.Host, c.Port)
-	payload, _ := json.Marshal(map[string]string{
-		"hostname": hostname,
-		"message":  message,
-		"appstate": appstate,
-	})
+// datetimeLayouts are the formats accepted for the *_dt fields when the timestamp is not a Unix timestamp.
+var datetimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// parseTime reads a point in time reported by roger as a Unix timestamp, falling back to its
+// datetime representation in UTC. It returns false if neither is set.
+func parseTime(unix, datetime string) (time.Time, bool) {
+	if secs, err := strconv.ParseFloat(strings.TrimSpace(unix), 64); err == nil {
+		if secs <= 0 {
+			return time.Time{}, false
+		}
+		return time.Unix(int64(secs), 0).UTC(), true
+	}
+
+	for _, layout := range datetimeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(datetime), time.UTC); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// UpdatedAt returns the time of the last update reported by roger, or false if it is unknown.
+func (s *State) UpdatedAt() (time.Time, bool) {
+	return parseTime(s.UpdatedTime, s.UpdatedTimeDT)
+}
 
-	body, status, err := c.doRequest(http.MethodPost, url, payload)
+// StateInput holds the fields sent to roger when creating or updating a state.
+// Fields left nil are not sent, so roger keeps their current value.
+type StateInput struct {
+	Hostname   string  `json:"hostname"`
+	Message    *string `json:"message,omitempty"`
+	AppState   *string `json:"appstate,omitempty"`
+	AppAlarmed *bool   `json:"app_alarmed,omitempty"`
+	HWAlarmed  *bool   `json:"hw_alarmed,omitempty"`
+	NCAlarmed  *bool   `json:"nc_alarmed,omitempty"`
+	OSAlarmed  *bool   `json:"os_alarmed,omitempty"`
+	Expires    *Expiry `json:"expires,omitempty"`
+}
+
+// Ptr returns a pointer to v, for filling the optional fields of StateInput.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// merge fills the fields of input that are not set with the values of current.
+func (input StateInput) merge(current *State) StateInput {
+	if input.Message == nil {
+		input.Message = &current.Message
+	}
+	if input.AppState == nil {
+		input.AppState = &current.AppState
+	}
+	if input.AppAlarmed == nil {
+		input.AppAlarmed = &current.AppAlarmed
+	}
+	if input.HWAlarmed == nil {
+		input.HWAlarmed = &current.HWAlarmed
+	}
+	if input.NCAlarmed == nil {
+		input.NCAlarmed = &current.NCAlarmed
+	}
+	if input.OSAlarmed == nil {
+		input.OSAlarmed = &current.OSAlarmed
+	}
+	if input.Expires == nil {
+		if expires, ok := current.ExpiresAt(); ok {
+			input.Expires = Ptr(Expiry(expires))
+		}
+	}
+	return input
+}
+
+func (c *Client) CreateState(ctx context.Context, input StateInput) (*State, error) {
+	url := c.endpointURL("state")
+	payload, _ := json.Marshal(input)
+
+	body, status, err := c.doRequest(ctx, http.MethodPost, url, payload)
 	if err != nil {
 		return nil, err
 	}
 
 	if status == http.StatusCreated || status == http.StatusNoContent || len(body) == 0 {
-		return c.GetState(hostname)
+		return c.GetState(ctx, input.Hostname)
 	}
 
 	var state State
@@ -50,10 +126,10 @@ func (c *Client) CreateState(hostname, message, appstate string) (*State, error)
 	return &state, nil
 }
 
-func (c *Client) GetState(hostname string) (*State, error) {
-	url := fmt.Sprintf("https://%s:%d/roger/v1/state/%s/", c.Host, c.Port, hostname)
+func (c *Client) GetState(ctx context.Context, hostname string) (*State, error) {
+	url := c.endpointURL("state", hostname)
 
-	body, _, err := c.doRequest(http.MethodGet, url, nil)
+	body, _, err := c.doRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -66,21 +142,38 @@ func (c *Client) GetState(hostname string) (*State, error) {
 	return &state, nil
 }
 
-func (c *Client) UpdateState(hostname, message, appstate string) (*State, error) {
-	url := fmt.Sprintf("https://%s:%d/roger/v1/state/%s/", c.Host, c.Port, hostname)
-	payload, _ := json.Marshal(map[string]string{
-		"hostname": hostname,
-		"message":  message,
-		"appstate": appstate,
-	})
+// UpdateState changes the fields of input that are set and keeps all others.
+// It uses PATCH and falls back to reading the current state and PUTting the merged
+// result if the server does not support PATCH.
+func (c *Client) UpdateState(ctx context.Context, input StateInput) (*State, error) {
+	ctx = c.logContext(ctx)
+	if !c.patchUnsupported.Load() {
+		state, err := c.writeState(ctx, http.MethodPatch, input)
+		if !hasStatus(err, http.StatusMethodNotAllowed, http.StatusNotImplemented) {
+			return state, err
+		}
+		c.patchUnsupported.Store(true)
+		c.logger().Debug(ctx, "roger API does not support PATCH, using read-modify-write", nil)
+	}
+
+	current, err := c.GetState(ctx, input.Hostname)
+	if err != nil {
+		return nil, err
+	}
+	return c.writeState(ctx, http.MethodPut, input.merge(current))
+}
+
+func (c *Client) writeState(ctx context.Context, method string, input StateInput) (*State, error) {
+	url := c.endpointURL("state", input.Hostname)
+	payload, _ := json.Marshal(input)
 
-	body, status, err := c.doRequest(http.MethodPut, url, payload)
+	body, status, err := c.doRequest(ctx, method, url, payload)
 	if err != nil {
 		return nil, err
 	}
 
 	if status == http.StatusOK || status == http.StatusNoContent || len(body) == 0 {
-		return c.GetState(hostname)
+		return c.GetState(ctx, input.Hostname)
 	}
 
 	var state State
@@ -90,15 +183,15 @@ func (c *Client) UpdateState(hostname, message, appstate string) (*State, error)
 	return &state, nil
 }
 
-func (c *Client) DeleteState(hostname string) error {
-	url := fmt.Sprintf("https://%s:%d/roger/v1/state/%s/", c.Host, c.Port, hostname)
-	body, status, err := c.doRequest(http.MethodDelete, url, nil)
+func (c *Client) DeleteState(ctx context.Context, hostname string) error {
+	url := c.endpointURL("state", hostname)
+	body, status, err := c.doRequest(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
 
 	if status != http.StatusNoContent && status != http.StatusOK {
-		return fmt.Errorf("failed to delete state: status=%d, body=%q", status, string(body))
+		return newAPIError(http.MethodDelete, url, status, body)
 	}
 
 	return nil
@@ -5,7 +5,11 @@
 package roger_test
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
 	"testing"
+	"time"
 
 	roger "roger/internal/client"
 
@@ -16,22 +20,27 @@ func TestStateCRUD(t *testing.T) {
 	host := "woger-direct.cern.ch"
 	port := 8201
 
-	cli, err := roger.NewClient(host, port)
+	cli, err := roger.NewClient(context.Background(), host, port)
 	require.NoError(t, err)
 
+	ctx := context.Background()
 	hostname := "tf-test-roger-123.cern.ch"
 
 	initialMessage := "Terraform test init"
 	initialAppState := "production"
 
 	t.Logf("Creating state for hostname: %s", hostname)
-	createdState, err := cli.CreateState(hostname, initialMessage, initialAppState)
+	createdState, err := cli.CreateState(ctx, roger.StateInput{
+		Hostname: hostname,
+		Message:  roger.Ptr(initialMessage),
+		AppState: roger.Ptr(initialAppState),
+	})
 	require.NoError(t, err)
 	require.Equal(t, hostname, createdState.Hostname)
 	require.Equal(t, initialAppState, createdState.AppState)
 
 	t.Log("Reading state...")
-	readState, err := cli.GetState(hostname)
+	readState, err := cli.GetState(ctx, hostname)
 	require.NoError(t, err)
 	require.Equal(t, createdState.Hostname, readState.Hostname)
 
@@ -39,21 +48,64 @@ func TestStateCRUD(t *testing.T) {
 	updatedMessage := "Terraform test updated"
 	updatedAppState := "draining"
 
-	updatedState, err := cli.UpdateState(hostname, updatedMessage, updatedAppState)
+	updatedState, err := cli.UpdateState(ctx, roger.StateInput{
+		Hostname: hostname,
+		Message:  roger.Ptr(updatedMessage),
+		AppState: roger.Ptr(updatedAppState),
+	})
 	require.NoError(t, err)
 	require.Equal(t, updatedMessage, updatedState.Message)
 	require.Equal(t, updatedAppState, updatedState.AppState)
 
 	t.Log("Final read to confirm update...")
-	finalState, err := cli.GetState(hostname)
+	finalState, err := cli.GetState(ctx, hostname)
 	require.NoError(t, err)
 	require.Equal(t, updatedMessage, finalState.Message)
 
 	t.Log("Deleting state...")
-	err = cli.DeleteState(hostname)
+	err = cli.DeleteState(ctx, hostname)
 	require.NoError(t, err)
 
 	t.Log("Verifying state is deleted...")
-	_, err = cli.GetState(hostname)
+	_, err = cli.GetState(ctx, hostname)
 	require.Error(t, err)
+	require.True(t, roger.IsNotFound(err))
+}
+
+func TestUpdateStateFallsBackToReadModifyWrite(t *testing.T) {
+	var put map[string]any
+	cli := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPatch:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		case http.MethodPut:
+			_ = json.NewDecoder(r.Body).Decode(&put)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hostname": "host.cern.ch", "appstate": "production", "message": "kept", "hw_alarmed": false}`))
+	}))
+
+	_, err := cli.UpdateState(context.Background(), roger.StateInput{
+		Hostname:   "host.cern.ch",
+		AppAlarmed: roger.Ptr(false),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "kept", put["message"])
+	require.Equal(t, "production", put["appstate"])
+	require.Equal(t, false, put["app_alarmed"])
+	require.Equal(t, false, put["hw_alarmed"])
+}
+
+func TestStateUpdatedAt(t *testing.T) {
+	updated, ok := (&roger.State{UpdatedTime: "1740830400", UpdatedTimeDT: "2025-03-01T12:00:00"}).UpdatedAt()
+	require.True(t, ok)
+	require.Equal(t, time.Unix(1740830400, 0).UTC(), updated)
+
+	updated, ok = (&roger.State{UpdatedTimeDT: "2025-03-01T12:00:00"}).UpdatedAt()
+	require.True(t, ok)
+	require.Equal(t, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), updated)
+
+	_, ok = (&roger.State{}).UpdatedAt()
+	require.False(t, ok)
 }
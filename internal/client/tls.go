@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// TLSOptions configures how the client verifies the roger server and authenticates to it.
+// The zero value uses the system roots.
+type TLSOptions struct {
+	// CAFile is the path of a PEM bundle of additional trusted CAs.
+	CAFile string
+	// CAPEM holds PEM encoded additional trusted CAs.
+	CAPEM string
+	// ClientCert and ClientKey are PEM encoded, or paths to PEM files, of a client certificate.
+	ClientCert string
+	ClientKey  string
+	// ServerName overrides the name used to verify the server certificate.
+	ServerName string
+	// InsecureSkipVerify disables the verification of the server certificate.
+	InsecureSkipVerify bool
+}
+
+// WithTLS configures the TLS settings of the connection to roger.
+func WithTLS(opts TLSOptions) Option {
+	return func(c *Client) {
+		c.tlsOptions = opts
+	}
+}
+
+func (o TLSOptions) isZero() bool {
+	return o == TLSOptions{}
+}
+
+func (o TLSOptions) config() (*tls.Config, error) {
+	conf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != "" || o.CAPEM != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if o.CAFile != "" {
+			pem, err := os.ReadFile(o.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no valid certificates found in CA file %q", o.CAFile)
+			}
+		}
+		if o.CAPEM != "" && !pool.AppendCertsFromPEM([]byte(o.CAPEM)) {
+			return nil, fmt.Errorf("no valid certificates found in CA PEM")
+		}
+		conf.RootCAs = pool
+	}
+
+	if o.ClientCert != "" || o.ClientKey != "" {
+		if o.ClientCert == "" || o.ClientKey == "" {
+			return nil, fmt.Errorf("client certificate and key must be set together")
+		}
+		certPEM, err := readPEM(o.ClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %w", err)
+		}
+		keyPEM, err := readPEM(o.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client key: %w", err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
+}
+
+// readPEM returns value if it is PEM encoded, otherwise the content of the file it names.
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
+
+func (o TLSOptions) transport() (http.RoundTripper, error) {
+	conf, err := o.config()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = conf
+	return transport, nil
+}
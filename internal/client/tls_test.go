@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package roger_test
+
+import (
+	"context"
+	"testing"
+
+	roger "roger/internal/client"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientWithInvalidTLSOptions(t *testing.T) {
+	writeKrb5Conf(t)
+
+	_, err := roger.NewClient(context.Background(), "localhost", 8201, roger.WithTLS(roger.TLSOptions{CAPEM: "not a certificate"}))
+	require.ErrorContains(t, err, "no valid certificates found in CA PEM")
+
+	_, err = roger.NewClient(context.Background(), "localhost", 8201, roger.WithTLS(roger.TLSOptions{ClientCert: "cert.pem"}))
+	require.ErrorContains(t, err, "client certificate and key must be set together")
+}
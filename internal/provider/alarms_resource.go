@@ -0,0 +1,297 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource                = &alarmsResource{}
+	_ resource.ResourceWithConfigure   = &alarmsResource{}
+	_ resource.ResourceWithImportState = &alarmsResource{}
+)
+
+// previousAlarmsKey is the private state key holding the alarm flags found when the resource was created.
+const previousAlarmsKey = "previous_alarms"
+
+func NewAlarmsResource() resource.Resource {
+	return &alarmsResource{}
+}
+
+type alarmsResourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	Hostname   types.String `tfsdk:"hostname"`
+	AppAlarmed types.Bool   `tfsdk:"app_alarmed"`
+	HWAlarmed  types.Bool   `tfsdk:"hw_alarmed"`
+	NCAlarmed  types.Bool   `tfsdk:"nc_alarmed"`
+	OSAlarmed  types.Bool   `tfsdk:"os_alarmed"`
+}
+
+// previousAlarms are the alarm flags restored when the resource is destroyed.
+type previousAlarms struct {
+	AppAlarmed bool `json:"app_alarmed"`
+	HWAlarmed  bool `json:"hw_alarmed"`
+	NCAlarmed  bool `json:"nc_alarmed"`
+	OSAlarmed  bool `json:"os_alarmed"`
+}
+
+// input only sends the configured alarm flags, appstate and message of the roger entry are kept.
+func (m *alarmsResourceModel) input() roger.StateInput {
+	return roger.StateInput{
+		Hostname:   m.Hostname.ValueString(),
+		AppAlarmed: knownBoolPointer(m.AppAlarmed),
+		HWAlarmed:  knownBoolPointer(m.HWAlarmed),
+		NCAlarmed:  knownBoolPointer(m.NCAlarmed),
+		OSAlarmed:  knownBoolPointer(m.OSAlarmed),
+	}
+}
+
+func (m *alarmsResourceModel) fromState(state *roger.State) {
+	m.ID = types.StringValue(state.Hostname)
+	m.Hostname = types.StringValue(state.Hostname)
+	m.AppAlarmed = types.BoolValue(state.AppAlarmed)
+	m.HWAlarmed = types.BoolValue(state.HWAlarmed)
+	m.NCAlarmed = types.BoolValue(state.NCAlarmed)
+	m.OSAlarmed = types.BoolValue(state.OSAlarmed)
+}
+
+type alarmsResource struct {
+	client *roger.Client
+}
+
+func (r *alarmsResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_alarms"
+}
+
+func (r *alarmsResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the alarm flags of an existing roger entry without touching its appstate or message. " +
+			"The flags found on creation are restored when the resource is destroyed.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Hostname of the roger entry.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"hostname": schema.StringAttribute{
+				Description: "Name of the host whose alarms are managed. The host must already have a roger entry.",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"app_alarmed": schema.BoolAttribute{
+				Description: "Whether application alarms of the host are enabled. Set to false to mask them. If not set, the value of roger is kept.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"hw_alarmed": schema.BoolAttribute{
+				Description: "Whether hardware alarms of the host are enabled. Set to false to mask them. If not set, the value of roger is kept.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"nc_alarmed": schema.BoolAttribute{
+				Description: "Whether network connectivity alarms of the host are enabled. Set to false to mask them. If not set, the value of roger is kept.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"os_alarmed": schema.BoolAttribute{
+				Description: "Whether operating system alarms of the host are enabled. Set to false to mask them. If not set, the value of roger is kept.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *alarmsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan alarmsResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, err := r.client.GetState(ctx, plan.Hostname.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading roger state",
+			"Could not read roger state Hostname "+plan.Hostname.ValueString()+", roger_alarms requires an existing entry: "+err.Error(),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(setPrivateJSON(ctx, resp.Private, previousAlarmsKey, previousAlarms{
+		AppAlarmed: current.AppAlarmed,
+		HWAlarmed:  current.HWAlarmed,
+		NCAlarmed:  current.NCAlarmed,
+		OSAlarmed:  current.OSAlarmed,
+	})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := r.client.UpdateState(ctx, plan.input())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Updating roger alarms",
+			"Could not update alarms, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	plan.fromState(state)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *alarmsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var readState alarmsResourceModel
+	diags := req.State.Get(ctx, &readState)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	hostname := readState.Hostname.ValueString()
+	if hostname == "" {
+		hostname = readState.ID.ValueString()
+	}
+
+	state, err := r.client.GetState(ctx, hostname)
+	if roger.IsNotFound(err) {
+		tflog.Warn(ctx, "roger state not found, removing alarms from Terraform state", map[string]any{
+			"hostname": hostname,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading roger state",
+			"Could not read roger state Hostname "+hostname+": "+err.Error(),
+		)
+		return
+	}
+
+	readState.fromState(state)
+	diags = resp.State.Set(ctx, &readState)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *alarmsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan alarmsResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := r.client.UpdateState(ctx, plan.input())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Updating roger alarms",
+			"Could not update alarms, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	plan.fromState(state)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *alarmsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state alarmsResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var previous previousAlarms
+	found, diags := getPrivateJSON(ctx, req.Private, previousAlarmsKey, &previous)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !found {
+		resp.Diagnostics.AddWarning(
+			"No previous roger alarms recorded",
+			"The alarm flags of "+state.Hostname.ValueString()+" found before the resource was created are unknown, e.g. because the resource was imported. "+
+				"The alarm flags are left unchanged.",
+		)
+		return
+	}
+
+	_, err := r.client.UpdateState(ctx, roger.StateInput{
+		Hostname:   state.Hostname.ValueString(),
+		AppAlarmed: &previous.AppAlarmed,
+		HWAlarmed:  &previous.HWAlarmed,
+		NCAlarmed:  &previous.NCAlarmed,
+		OSAlarmed:  &previous.OSAlarmed,
+	})
+	if roger.IsNotFound(err) {
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Restoring roger alarms",
+			"Could not restore previous alarms, unexpected error: "+err.Error(),
+		)
+		return
+	}
+}
+
+func (r *alarmsResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*roger.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *roger.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
+
+func (r *alarmsResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
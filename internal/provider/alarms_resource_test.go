@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/require"
+)
+
+// alarmsState converts m to a state of roger_alarms.
+func alarmsState(t *testing.T, m alarmsResourceModel) tfsdk.State {
+	t.Helper()
+	ctx := context.Background()
+
+	var schemaResp resource.SchemaResponse
+	(&alarmsResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}
+	require.False(t, state.Set(ctx, &m).HasError())
+	return state
+}
+
+func TestAlarmsDeleteWithoutRecordedAlarmsWarns(t *testing.T) {
+	fake := newFakeRoger(map[string]map[string]any{
+		"host.cern.ch": {"hostname": "host.cern.ch", "appstate": "production", "hw_alarmed": false},
+	})
+	r := &alarmsResource{client: newTestClient(t, fake)}
+
+	// An imported resource has no private state.
+	state := alarmsState(t, alarmsResourceModel{
+		ID:        types.StringValue("host.cern.ch"),
+		Hostname:  types.StringValue("host.cern.ch"),
+		HWAlarmed: types.BoolValue(false),
+	})
+	resp := resource.DeleteResponse{State: state}
+	r.Delete(context.Background(), resource.DeleteRequest{State: state}, &resp)
+
+	require.False(t, resp.Diagnostics.HasError())
+	require.Len(t, resp.Diagnostics.Warnings(), 1)
+	require.Equal(t, "No previous roger alarms recorded", resp.Diagnostics.Warnings()[0].Summary())
+	require.Empty(t, fake.writes)
+}
+
+func TestAlarmsInputSendsOnlyConfiguredFlags(t *testing.T) {
+	m := alarmsResourceModel{
+		Hostname:   types.StringValue("host.cern.ch"),
+		AppAlarmed: types.BoolUnknown(),
+		HWAlarmed:  types.BoolValue(false),
+		NCAlarmed:  types.BoolValue(true),
+		OSAlarmed:  types.BoolUnknown(),
+	}
+
+	require.Equal(t, roger.StateInput{
+		Hostname:  "host.cern.ch",
+		HWAlarmed: roger.Ptr(false),
+		NCAlarmed: roger.Ptr(true),
+	}, m.input())
+}
+
+func TestAlarmsRestoreFlagsOnDestroy(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeRoger(map[string]map[string]any{
+		"host.cern.ch": {
+			"hostname":    "host.cern.ch",
+			"appstate":    "draining",
+			"message":     "intervention",
+			"app_alarmed": true,
+			"hw_alarmed":  true,
+			"nc_alarmed":  true,
+			"os_alarmed":  false,
+		},
+	})
+	server := testServer(t, newTestClient(t, fake))
+	r := &alarmsResource{}
+
+	planned := alarmsResourceModel{
+		ID:         types.StringUnknown(),
+		Hostname:   types.StringValue("host.cern.ch"),
+		AppAlarmed: types.BoolUnknown(),
+		HWAlarmed:  types.BoolValue(false),
+		NCAlarmed:  types.BoolUnknown(),
+		OSAlarmed:  types.BoolUnknown(),
+	}
+	config := alarmsResourceModel{
+		Hostname:  planned.Hostname,
+		HWAlarmed: planned.HWAlarmed,
+	}
+	created, err := server.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     "roger_alarms",
+		PriorState:   resourceValue(t, r, nil),
+		PlannedState: resourceValue(t, r, planned),
+		Config:       resourceValue(t, r, config),
+	})
+	require.NoError(t, err)
+	require.Empty(t, created.Diagnostics)
+
+	// Only the configured flag is written, appstate, message and the other flags are kept.
+	require.Equal(t, []string{"PATCH host.cern.ch"}, fake.writes)
+	entry := fake.entry("host.cern.ch")
+	require.Equal(t, false, entry["hw_alarmed"])
+	require.Equal(t, true, entry["app_alarmed"])
+	require.Equal(t, "draining", entry["appstate"])
+	require.Equal(t, "intervention", entry["message"])
+
+	var state alarmsResourceModel
+	resourceModel(t, r, created.NewState, &state)
+	require.Equal(t, alarmsResourceModel{
+		ID:         types.StringValue("host.cern.ch"),
+		Hostname:   types.StringValue("host.cern.ch"),
+		AppAlarmed: types.BoolValue(true),
+		HWAlarmed:  types.BoolValue(false),
+		NCAlarmed:  types.BoolValue(true),
+		OSAlarmed:  types.BoolValue(false),
+	}, state)
+
+	// Destroying the resource puts back the flags found on creation.
+	fake.entries["host.cern.ch"]["nc_alarmed"] = false
+	destroyed, err := server.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       "roger_alarms",
+		PriorState:     created.NewState,
+		PlannedState:   resourceValue(t, r, nil),
+		Config:         resourceValue(t, r, nil),
+		PlannedPrivate: created.Private,
+	})
+	require.NoError(t, err)
+	require.Empty(t, destroyed.Diagnostics)
+
+	entry = fake.entry("host.cern.ch")
+	require.Equal(t, true, entry["hw_alarmed"])
+	require.Equal(t, true, entry["nc_alarmed"])
+	require.Equal(t, false, entry["os_alarmed"])
+	require.Equal(t, "draining", entry["appstate"])
+	require.Equal(t, "intervention", entry["message"])
+}
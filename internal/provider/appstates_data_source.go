@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSource              = &appStatesDataSource{}
+	_ datasource.DataSourceWithConfigure = &appStatesDataSource{}
+)
+
+func NewAppStatesDataSource() datasource.DataSource {
+	return &appStatesDataSource{}
+}
+
+type appStatesDataSourceModel struct {
+	AppStates types.List `tfsdk:"appstates"`
+}
+
+type appStatesDataSource struct {
+	client *roger.Client
+}
+
+func (d *appStatesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_appstates"
+}
+
+func (d *appStatesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the appstates accepted by roger.",
+		Attributes: map[string]schema.Attribute{
+			"appstates": schema.ListAttribute{
+				Description: "Appstates accepted by roger. These are allowed_appstates of the provider if set, otherwise the appstates provided by the roger server, " +
+					"or 'production', 'draining' and 'quiesce' if it does not provide them.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+		},
+	}
+}
+
+func (d *appStatesDataSource) Read(ctx context.Context, _ datasource.ReadRequest, resp *datasource.ReadResponse) {
+	appStates, err := d.client.AppStates(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading roger appstates",
+			"Could not read the appstates accepted by roger: "+err.Error(),
+		)
+		return
+	}
+
+	list, diags := types.ListValueFrom(ctx, types.StringType, appStates)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, appStatesDataSourceModel{AppStates: list})
+	resp.Diagnostics.Append(diags...)
+}
+
+func (d *appStatesDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*roger.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *roger.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
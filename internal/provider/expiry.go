@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"time"
+
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ validator.String    = expiryValidator{}
+	_ planmodifier.String = expiresAtModifier{}
+)
+
+// expiryValidator checks that a value is an RFC3339 timestamp or a positive duration.
+type expiryValidator struct{}
+
+func (v expiryValidator) Description(_ context.Context) string {
+	return "value must be an RFC3339 timestamp or a positive duration such as \"4h\""
+}
+
+func (v expiryValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v expiryValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := roger.ParseExpiry(req.ConfigValue.ValueString(), time.Now()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid roger expiry",
+			"The expiry must be an RFC3339 timestamp such as \"2025-03-01T18:00:00Z\" or a duration such as \"4h\": "+err.Error(),
+		)
+	}
+}
+
+// isAbsoluteExpiry reports whether an expires value is an RFC3339 timestamp rather than a duration.
+func isAbsoluteExpiry(value string) bool {
+	_, err := time.Parse(time.RFC3339, value)
+	return err == nil
+}
+
+// expiresAtModifier keeps the known expires_at as long as expires does not change.
+type expiresAtModifier struct{}
+
+func (m expiresAtModifier) Description(_ context.Context) string {
+	return "Keeps the absolute expiry unless expires changes."
+}
+
+func (m expiresAtModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m expiresAtModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() || !req.PlanValue.IsUnknown() {
+		return
+	}
+
+	var planned, current types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("expires"), &planned)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("expires"), &current)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if planned.Equal(current) {
+		resp.PlanValue = req.StateValue
+	}
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// logSubsystem is the tflog subsystem used for the output of the roger client.
+const logSubsystem = "roger"
+
+var _ roger.ContextLogger = tflogLogger{}
+
+// subsystemKey marks a context in which the "roger" tflog subsystem has already been set up.
+type subsystemKey struct{}
+
+// tflogLogger forwards the roger client logs to the "roger" tflog subsystem.
+type tflogLogger struct {
+	maskedFields []string
+}
+
+// NewContext sets up the "roger" subsystem once per client call, the log methods reuse it.
+func (l tflogLogger) NewContext(ctx context.Context) context.Context {
+	if ctx.Value(subsystemKey{}) != nil {
+		return ctx
+	}
+	ctx = tflog.NewSubsystem(ctx, logSubsystem)
+	if len(l.maskedFields) > 0 {
+		ctx = tflog.SubsystemMaskFieldValuesWithFieldKeys(ctx, logSubsystem, l.maskedFields...)
+	}
+	return context.WithValue(ctx, subsystemKey{}, true)
+}
+
+func (l tflogLogger) Trace(ctx context.Context, msg string, fields map[string]any) {
+	tflog.SubsystemTrace(l.NewContext(ctx), logSubsystem, msg, fields)
+}
+
+func (l tflogLogger) Debug(ctx context.Context, msg string, fields map[string]any) {
+	tflog.SubsystemDebug(l.NewContext(ctx), logSubsystem, msg, fields)
+}
+
+func (l tflogLogger) Warn(ctx context.Context, msg string, fields map[string]any) {
+	tflog.SubsystemWarn(l.NewContext(ctx), logSubsystem, msg, fields)
+}
@@ -6,10 +6,13 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"os"
 	roger "roger/internal/client"
 	"strconv"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
@@ -32,8 +35,24 @@ func New(version string) func() provider.Provider {
 }
 
 type rogerProviderModel struct {
-	Host types.String `tfsdk:"host"`
-	Port types.Number `tfsdk:"port"`
+	Endpoint           types.String `tfsdk:"endpoint"`
+	Host               types.String `tfsdk:"host"`
+	Port               types.Number `tfsdk:"port"`
+	RetryMaxAttempts   types.Int64  `tfsdk:"retry_max_attempts"`
+	RetryMaxBackoff    types.String `tfsdk:"retry_max_backoff"`
+	LogMaskedFields    types.List   `tfsdk:"log_masked_fields"`
+	AllowedAppStates   types.List   `tfsdk:"allowed_appstates"`
+	Principal          types.String `tfsdk:"principal"`
+	KeytabPath         types.String `tfsdk:"keytab_path"`
+	Password           types.String `tfsdk:"password"`
+	CAFile             types.String `tfsdk:"ca_file"`
+	CAPEM              types.String `tfsdk:"ca_pem"`
+	ClientCert         types.String `tfsdk:"client_cert"`
+	ClientKey          types.String `tfsdk:"client_key"`
+	TLSServerName      types.String `tfsdk:"tls_server_name"`
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+	CanonicalizeHost   types.Bool   `tfsdk:"canonicalize_host"`
+	ServicePrincipal   types.String `tfsdk:"service_principal"`
 }
 
 type rogerProvider struct {
@@ -49,6 +68,10 @@ func (p *rogerProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp
 	resp.Schema = schema.Schema{
 		Description: "Interact with roger.",
 		Attributes: map[string]schema.Attribute{
+			"endpoint": schema.StringAttribute{
+				Description: "Full base URL of the roger API including scheme and path, e.g. 'https://woger-direct.cern.ch:8201/roger/v1/'. Takes precedence over host and port. May also be provided via ROGER_ENDPOINT environment variable.",
+				Optional:    true,
+			},
 			"host": schema.StringAttribute{
 				Description: "URI for roger API. May also be provided via ROGER_HOST environment variable.",
 				Optional:    true,
@@ -57,6 +80,70 @@ func (p *rogerProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp
 				Description: "Port for roger API. May also be provided via ROGER_PORT environment variable.",
 				Optional:    true,
 			},
+			"retry_max_attempts": schema.Int64Attribute{
+				Description: "Maximum number of attempts for a roger API request failing with a transient error, including the first one. Set to 1 to disable retries. Defaults to 4.",
+				Optional:    true,
+			},
+			"retry_max_backoff": schema.StringAttribute{
+				Description: "Maximum wait between two attempts as a Go duration, e.g. '30s'. Also caps waits requested by the server via Retry-After. Defaults to '30s'.",
+				Optional:    true,
+			},
+			"log_masked_fields": schema.ListAttribute{
+				Description: "Names of JSON body fields and HTTP headers whose values are masked in the provider logs. Authentication headers are always masked.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"allowed_appstates": schema.ListAttribute{
+				Description: "Appstates accepted by roger_state, for sites with custom states. Defaults to the appstates provided by the roger server, or 'production', 'draining' and 'quiesce' if it does not provide them.",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"principal": schema.StringAttribute{
+				Description: "Kerberos principal to log in as, e.g. 'svc@CERN.CH'. The default realm of krb5.conf is used if none is given. May also be provided via ROGER_PRINCIPAL environment variable.",
+				Optional:    true,
+			},
+			"keytab_path": schema.StringAttribute{
+				Description: "Path to a keytab holding the keys of principal. If neither keytab_path nor password are set, the credential cache from KRB5CCNAME or the default credential cache is used. May also be provided via ROGER_KEYTAB environment variable.",
+				Optional:    true,
+			},
+			"password": schema.StringAttribute{
+				Description: "Password of principal, used to obtain a ticket if no keytab is configured. May also be provided via ROGER_PASSWORD environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"ca_file": schema.StringAttribute{
+				Description: "Path to a PEM bundle of CAs trusted in addition to the system roots, e.g. the CERN Grid CA.",
+				Optional:    true,
+			},
+			"ca_pem": schema.StringAttribute{
+				Description: "PEM encoded CAs trusted in addition to the system roots.",
+				Optional:    true,
+			},
+			"client_cert": schema.StringAttribute{
+				Description: "PEM encoded client certificate, or path to it, presented to the roger server. Requires client_key.",
+				Optional:    true,
+			},
+			"client_key": schema.StringAttribute{
+				Description: "PEM encoded private key, or path to it, of client_cert.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"tls_server_name": schema.StringAttribute{
+				Description: "Name used to verify the certificate of the roger server, if it differs from the host.",
+				Optional:    true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				Description: "Disable the verification of the certificate of the roger server. Only use this for testing.",
+				Optional:    true,
+			},
+			"canonicalize_host": schema.BoolAttribute{
+				Description: "Resolve the roger host to its canonical name via forward and reverse DNS lookups (IPv4 and IPv6) before connecting. Disable this if DNS is not available or has no PTR records. Defaults to true.",
+				Optional:    true,
+			},
+			"service_principal": schema.StringAttribute{
+				Description: "Kerberos service principal of the roger server, e.g. 'HTTP/woger-direct.cern.ch'. A name without service is prefixed with 'HTTP/'. Defaults to the principal derived from the host.",
+				Optional:    true,
+			},
 		},
 	}
 }
@@ -89,10 +176,98 @@ func (p *rogerProvider) Configure(ctx context.Context, req provider.ConfigureReq
 		)
 	}
 
+	if config.Endpoint.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("endpoint"),
+			"Unknown roger API Endpoint",
+			"The provider cannot create the roger API client as there is an unknown configuration value for the roger endpoint. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ROGER_ENDPOINT environment variable.",
+		)
+	}
+
+	if !config.Endpoint.IsNull() && (!config.Host.IsNull() || !config.Port.IsNull()) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("endpoint"),
+			"Conflicting roger API configuration",
+			"The endpoint already contains the roger host and port. Either set endpoint or host and port, not both.",
+		)
+	}
+
+	if config.Principal.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("principal"),
+			"Unknown Kerberos principal",
+			"The provider cannot create the roger API client as there is an unknown configuration value for the Kerberos principal. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ROGER_PRINCIPAL environment variable.",
+		)
+	}
+
+	if config.Password.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password"),
+			"Unknown Kerberos password",
+			"The provider cannot create the roger API client as there is an unknown configuration value for the Kerberos password. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ROGER_PASSWORD environment variable.",
+		)
+	}
+
+	if config.KeytabPath.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("keytab_path"),
+			"Unknown Kerberos keytab path",
+			"The provider cannot create the roger API client as there is an unknown configuration value for the keytab path. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the ROGER_KEYTAB environment variable.",
+		)
+	}
+
+	if config.CanonicalizeHost.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("canonicalize_host"),
+			"Unknown roger host canonicalization",
+			"The provider cannot create the roger API client as there is an unknown configuration value for canonicalize_host. "+
+				"Either target apply the source of the value first or set the value statically in the configuration.",
+		)
+	}
+
+	if config.ServicePrincipal.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("service_principal"),
+			"Unknown Kerberos service principal",
+			"The provider cannot create the roger API client as there is an unknown configuration value for the Kerberos service principal. "+
+				"Either target apply the source of the value first or set the value statically in the configuration.",
+		)
+	}
+
+	for _, tls := range []struct {
+		attribute string
+		value     attr.Value
+	}{
+		{"ca_file", config.CAFile},
+		{"ca_pem", config.CAPEM},
+		{"client_cert", config.ClientCert},
+		{"client_key", config.ClientKey},
+		{"tls_server_name", config.TLSServerName},
+		{"insecure_skip_verify", config.InsecureSkipVerify},
+	} {
+		if tls.value.IsUnknown() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(tls.attribute),
+				"Unknown roger TLS setting",
+				fmt.Sprintf("The provider cannot create the roger API client as there is an unknown configuration value for %s. ", tls.attribute)+
+					"Either target apply the source of the value first or set the value statically in the configuration.",
+			)
+		}
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	endpoint := os.Getenv("ROGER_ENDPOINT")
+	if !config.Endpoint.IsNull() {
+		endpoint = config.Endpoint.ValueString()
+	}
+
 	host := os.Getenv("ROGER_HOST")
 	if host == "" {
 		host = "woger-direct.cern.ch"
@@ -136,16 +311,142 @@ func (p *rogerProvider) Configure(ctx context.Context, req provider.ConfigureReq
 		)
 	}
 
+	retry := roger.DefaultRetryPolicy()
+	if !config.RetryMaxAttempts.IsNull() && !config.RetryMaxAttempts.IsUnknown() {
+		attempts := config.RetryMaxAttempts.ValueInt64()
+		if attempts < 1 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("retry_max_attempts"),
+				"Invalid roger retry attempts",
+				fmt.Sprintf("retry_max_attempts must be at least 1, got %d.", attempts),
+			)
+		}
+		retry.MaxAttempts = int(attempts)
+	}
+
+	if !config.RetryMaxBackoff.IsNull() && !config.RetryMaxBackoff.IsUnknown() {
+		backoff, err := time.ParseDuration(config.RetryMaxBackoff.ValueString())
+		if err != nil || backoff <= 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("retry_max_backoff"),
+				"Invalid roger retry backoff",
+				fmt.Sprintf("retry_max_backoff must be a positive duration such as '30s', got %q.", config.RetryMaxBackoff.ValueString()),
+			)
+		}
+		retry.MaxBackoff = backoff
+	}
+
+	var maskedFields []string
+	if !config.LogMaskedFields.IsNull() && !config.LogMaskedFields.IsUnknown() {
+		resp.Diagnostics.Append(config.LogMaskedFields.ElementsAs(ctx, &maskedFields, false)...)
+	}
+
+	var allowedAppStates []string
+	if !config.AllowedAppStates.IsNull() && !config.AllowedAppStates.IsUnknown() {
+		resp.Diagnostics.Append(config.AllowedAppStates.ElementsAs(ctx, &allowedAppStates, false)...)
+	}
+
+	principal := os.Getenv("ROGER_PRINCIPAL")
+	if !config.Principal.IsNull() {
+		principal = config.Principal.ValueString()
+	}
+
+	keytabPath := os.Getenv("ROGER_KEYTAB")
+	if !config.KeytabPath.IsNull() {
+		keytabPath = config.KeytabPath.ValueString()
+	}
+
+	password := os.Getenv("ROGER_PASSWORD")
+	if !config.Password.IsNull() {
+		password = config.Password.ValueString()
+	}
+
+	if (keytabPath != "" || password != "") && principal == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("principal"),
+			"Missing Kerberos principal",
+			"The provider cannot log in with a keytab or password without a principal. "+
+				"Set the principal value in the configuration or use the ROGER_PRINCIPAL environment variable.",
+		)
+	}
+
+	if keytabPath != "" && password != "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password"),
+			"Conflicting Kerberos credentials",
+			"The provider can either log in with a keytab or with a password, not both. "+
+				"Unset either keytab_path (ROGER_KEYTAB) or password (ROGER_PASSWORD).",
+		)
+	}
+
+	tlsOptions := roger.TLSOptions{
+		CAFile:             config.CAFile.ValueString(),
+		CAPEM:              config.CAPEM.ValueString(),
+		ClientCert:         config.ClientCert.ValueString(),
+		ClientKey:          config.ClientKey.ValueString(),
+		ServerName:         config.TLSServerName.ValueString(),
+		InsecureSkipVerify: config.InsecureSkipVerify.ValueBool(),
+	}
+
+	if (tlsOptions.ClientCert == "") != (tlsOptions.ClientKey == "") {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("client_key"),
+			"Incomplete roger client certificate",
+			"client_cert and client_key must either both be set or both be unset.",
+		)
+	}
+
+	if tlsOptions.InsecureSkipVerify {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("insecure_skip_verify"),
+			"roger server certificate is not verified",
+			"insecure_skip_verify is enabled, the provider accepts any certificate presented by the roger server.",
+		)
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	ctx = tflog.SetField(ctx, "roger_host", host)
-	ctx = tflog.SetField(ctx, "roger_port", port)
+	if endpoint != "" {
+		ctx = tflog.SetField(ctx, "roger_endpoint", endpoint)
+	} else {
+		ctx = tflog.SetField(ctx, "roger_host", host)
+		ctx = tflog.SetField(ctx, "roger_port", port)
+	}
 
 	tflog.Debug(ctx, "Creating roger client")
 
-	client, err := roger.NewClient(host, port)
+	opts := []roger.Option{
+		roger.WithRetryPolicy(retry),
+		roger.WithLogger(tflogLogger{maskedFields: maskedFields}),
+		roger.WithMaskedFields(maskedFields...),
+	}
+	if endpoint != "" {
+		opts = append(opts, roger.WithEndpoint(endpoint))
+	}
+	if !config.CanonicalizeHost.IsNull() {
+		opts = append(opts, roger.WithHostCanonicalization(config.CanonicalizeHost.ValueBool()))
+	}
+	if spn := config.ServicePrincipal.ValueString(); spn != "" {
+		opts = append(opts, roger.WithServicePrincipal(spn))
+	}
+	if len(allowedAppStates) > 0 {
+		opts = append(opts, roger.WithAllowedAppStates(allowedAppStates...))
+	}
+	if tlsOptions != (roger.TLSOptions{}) {
+		opts = append(opts, roger.WithTLS(tlsOptions))
+	}
+	switch {
+	case keytabPath != "":
+		ctx = tflog.SetField(ctx, "roger_principal", principal)
+		opts = append(opts, roger.WithKeytab(principal, keytabPath))
+	case password != "":
+		ctx = tflog.SetField(ctx, "roger_principal", principal)
+		opts = append(opts, roger.WithPassword(principal, password))
+	}
+
+	client, err := roger.NewClient(ctx, host, port, opts...)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Create roger API Client",
@@ -165,9 +466,16 @@ func (p *rogerProvider) Configure(ctx context.Context, req provider.ConfigureReq
 func (p *rogerProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewStateResource,
+		NewAlarmsResource,
+		NewStatesResource,
+		NewRollingDrainResource,
 	}
 }
 
 func (p *rogerProvider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		NewAppStatesDataSource,
+		NewStateDataSource,
+		NewStatesDataSource,
+	}
 }
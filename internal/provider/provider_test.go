@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRoger serves roger entries from memory. Writes are recorded as "METHOD hostname".
+type fakeRoger struct {
+	mu      sync.Mutex
+	entries map[string]map[string]any
+	writes  []string
+	// lists records the query of every listing.
+	lists []string
+	// failing hosts get an internal server error for every request.
+	failing map[string]bool
+}
+
+func newFakeRoger(entries map[string]map[string]any) *fakeRoger {
+	if entries == nil {
+		entries = map[string]map[string]any{}
+	}
+	return &fakeRoger{entries: entries, failing: map[string]bool{}}
+}
+
+func (f *fakeRoger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Other endpoints, e.g. the appstate catalogue, are not provided.
+	rest, ok := strings.CutPrefix(r.URL.Path, "/roger/v1/state/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	hostname := strings.Trim(rest, "/")
+	var body map[string]any
+	if r.Method == http.MethodPost || r.Method == http.MethodPatch {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if hostname == "" {
+			hostname, _ = body["hostname"].(string)
+		}
+	}
+	if r.Method != http.MethodGet {
+		f.writes = append(f.writes, r.Method+" "+hostname)
+	}
+	if f.failing[hostname] {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	entry, ok := f.entries[hostname]
+	switch {
+	case r.Method == http.MethodGet && hostname == "":
+		f.lists = append(f.lists, r.URL.RawQuery)
+		hostnames := make([]string, 0, len(f.entries))
+		for hostname := range f.entries {
+			hostnames = append(hostnames, hostname)
+		}
+		sort.Strings(hostnames)
+		list := make([]map[string]any, 0, len(hostnames))
+		for _, hostname := range hostnames {
+			if appState := r.URL.Query().Get("appstate"); appState == "" || f.entries[hostname]["appstate"] == appState {
+				list = append(list, f.entries[hostname])
+			}
+		}
+		writeJSON(w, http.StatusOK, list)
+	case r.Method == http.MethodPost:
+		if ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.entries[hostname] = body
+		writeJSON(w, http.StatusCreated, body)
+	case !ok:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, entry)
+	case r.Method == http.MethodPatch:
+		for key, value := range body {
+			entry[key] = value
+		}
+		writeJSON(w, http.StatusOK, entry)
+	case r.Method == http.MethodDelete:
+		delete(f.entries, hostname)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// entry returns a copy of the roger entry of hostname, or nil if there is none.
+func (f *fakeRoger) entry(hostname string) map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry, ok := f.entries[hostname]
+	if !ok {
+		return nil
+	}
+	copied := make(map[string]any, len(entry))
+	for key, value := range entry {
+		copied[key] = value
+	}
+	return copied
+}
+
+// testProvider is the roger provider without configuration, handing client to its resources.
+type testProvider struct {
+	rogerProvider
+	client *roger.Client
+}
+
+func (p *testProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{}
+}
+
+func (p *testProvider) Configure(_ context.Context, _ provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	resp.DataSourceData = p.client
+	resp.ResourceData = p.client
+}
+
+// testServer returns a configured provider server whose resources use client.
+func testServer(t *testing.T, client *roger.Client) tfprotov6.ProviderServer {
+	t.Helper()
+	ctx := context.Background()
+
+	server, err := providerserver.NewProtocol6WithError(&testProvider{client: client})()
+	require.NoError(t, err)
+
+	config, err := tfprotov6.NewDynamicValue(tftypes.Object{}, tftypes.NewValue(tftypes.Object{}, map[string]tftypes.Value{}))
+	require.NoError(t, err)
+	resp, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{Config: &config})
+	require.NoError(t, err)
+	require.Empty(t, resp.Diagnostics)
+	return server
+}
+
+// resourceValue converts the model m of r to a protocol value, a nil m is a null value.
+func resourceValue(t *testing.T, r resource.Resource, m any) *tfprotov6.DynamicValue {
+	t.Helper()
+	ctx := context.Background()
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}
+	if m != nil {
+		require.False(t, state.Set(ctx, m).HasError())
+	}
+	v, err := tfprotov6.NewDynamicValue(state.Raw.Type(), state.Raw)
+	require.NoError(t, err)
+	return &v
+}
+
+// resourceModel decodes the protocol value v of r into m.
+func resourceModel(t *testing.T, r resource.Resource, v *tfprotov6.DynamicValue, m any) {
+	t.Helper()
+	ctx := context.Background()
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+
+	raw, err := v.Unmarshal(schemaResp.Schema.Type().TerraformType(ctx))
+	require.NoError(t, err)
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: raw}
+	require.False(t, state.Get(ctx, m).HasError())
+}
+
+// configureProvider runs Configure of the roger provider with the configuration m.
+func configureProvider(t *testing.T, m rogerProviderModel) provider.ConfigureResponse {
+	t.Helper()
+	ctx := context.Background()
+	p := New("test")()
+
+	var schemaResp provider.SchemaResponse
+	p.Schema(ctx, provider.SchemaRequest{}, &schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+
+	if m.LogMaskedFields.ElementType(ctx) == nil {
+		m.LogMaskedFields = types.ListNull(types.StringType)
+	}
+	if m.AllowedAppStates.ElementType(ctx) == nil {
+		m.AllowedAppStates = types.ListNull(types.StringType)
+	}
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}
+	require.False(t, state.Set(ctx, &m).HasError())
+
+	var resp provider.ConfigureResponse
+	p.Configure(ctx, provider.ConfigureRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: state.Raw}}, &resp)
+	return resp
+}
+
+func TestConfigureRejectsUnknownValues(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		model     rogerProviderModel
+		attribute string
+	}{
+		{"canonicalize_host", rogerProviderModel{CanonicalizeHost: types.BoolUnknown()}, "canonicalize_host"},
+		{"service_principal", rogerProviderModel{ServicePrincipal: types.StringUnknown()}, "service_principal"},
+		{"ca_file", rogerProviderModel{CAFile: types.StringUnknown()}, "ca_file"},
+		{"ca_pem", rogerProviderModel{CAPEM: types.StringUnknown()}, "ca_pem"},
+		{"client_cert", rogerProviderModel{ClientCert: types.StringUnknown()}, "client_cert"},
+		{"client_key", rogerProviderModel{ClientKey: types.StringUnknown()}, "client_key"},
+		{"tls_server_name", rogerProviderModel{TLSServerName: types.StringUnknown()}, "tls_server_name"},
+		{"insecure_skip_verify", rogerProviderModel{InsecureSkipVerify: types.BoolUnknown()}, "insecure_skip_verify"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := configureProvider(t, tt.model)
+			require.Len(t, resp.Diagnostics.Errors(), 1)
+			require.Contains(t, resp.Diagnostics.Errors()[0].Detail(), "unknown configuration value")
+			withPath, ok := resp.Diagnostics.Errors()[0].(diag.DiagnosticWithPath)
+			require.True(t, ok)
+			require.True(t, withPath.Path().Equal(path.Root(tt.attribute)))
+			require.Nil(t, resp.ResourceData)
+		})
+	}
+}
@@ -0,0 +1,393 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	roger "roger/internal/client"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource                   = &rollingDrainResource{}
+	_ resource.ResourceWithConfigure      = &rollingDrainResource{}
+	_ resource.ResourceWithValidateConfig = &rollingDrainResource{}
+	_ resource.ResourceWithModifyPlan     = &rollingDrainResource{}
+)
+
+const (
+	// drainingAppState is the appstate hosts are moved to by roger_rolling_drain.
+	drainingAppState = "draining"
+	// previousStatesKey is the private state key holding the states found before the hosts were changed.
+	previousStatesKey = "previous_states"
+)
+
+func NewRollingDrainResource() resource.Resource {
+	return &rollingDrainResource{}
+}
+
+type rollingDrainResourceModel struct {
+	ID                    types.String `tfsdk:"id"`
+	Hostnames             []string     `tfsdk:"hostnames"`
+	BatchSize             types.Int64  `tfsdk:"batch_size"`
+	MaxUnavailablePercent types.Int64  `tfsdk:"max_unavailable_percent"`
+	Pause                 types.String `tfsdk:"pause"`
+	Message               types.String `tfsdk:"message"`
+	RestoreTrigger        types.String `tfsdk:"restore_trigger"`
+	Restored              types.Bool   `tfsdk:"restored"`
+}
+
+// batchSize returns the number of hosts drained at the same time, one host unless configured otherwise.
+func (m *rollingDrainResourceModel) batchSize() int {
+	switch {
+	case !m.BatchSize.IsNull():
+		return int(m.BatchSize.ValueInt64())
+	case !m.MaxUnavailablePercent.IsNull():
+		return max(1, len(m.Hostnames)*int(m.MaxUnavailablePercent.ValueInt64())/100)
+	default:
+		return 1
+	}
+}
+
+// pause returns the time waited between two batches. The value has been checked by ValidateConfig.
+func (m *rollingDrainResourceModel) pause() time.Duration {
+	d, _ := time.ParseDuration(m.Pause.ValueString())
+	return d
+}
+
+type rollingDrainResource struct {
+	client *roger.Client
+}
+
+func (r *rollingDrainResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rolling_drain"
+}
+
+func (r *rollingDrainResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Moves hosts to 'draining' in batches, waiting between batches. " +
+			"The hosts are returned to their previous appstate when restore_trigger changes or the resource is destroyed.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier of the set of hosts.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"hostnames": schema.ListAttribute{
+				Description: "Hosts to drain, in order. The hosts must already have a roger entry.",
+				Required:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"batch_size": schema.Int64Attribute{
+				Description: "Number of hosts drained at the same time. Conflicts with max_unavailable_percent. Defaults to 1.",
+				Optional:    true,
+			},
+			"max_unavailable_percent": schema.Int64Attribute{
+				Description: "Percentage of the hosts drained at the same time, rounded down to at least one host. Conflicts with batch_size.",
+				Optional:    true,
+			},
+			"pause": schema.StringAttribute{
+				Description: "Time waited between two batches, e.g. '10m'. Defaults to '0s'.",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("0s"),
+			},
+			"message": schema.StringAttribute{
+				Description: "Alert Message set while the hosts are drained. If not set, the message of roger is kept.",
+				Optional:    true,
+			},
+			"restore_trigger": schema.StringAttribute{
+				Description: "Arbitrary value, when it changes the hosts are returned to their previous appstate and message.",
+				Optional:    true,
+			},
+			"restored": schema.BoolAttribute{
+				Description: "Whether the hosts have been returned to their previous appstate by restore_trigger.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *rollingDrainResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var batchSize, maxUnavailable types.Int64
+	var pause types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("batch_size"), &batchSize)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("max_unavailable_percent"), &maxUnavailable)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("pause"), &pause)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !batchSize.IsNull() && !maxUnavailable.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_unavailable_percent"),
+			"Conflicting roger batch settings",
+			"Either batch_size or max_unavailable_percent can be set, not both.",
+		)
+	}
+	if !batchSize.IsNull() && !batchSize.IsUnknown() && batchSize.ValueInt64() < 1 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("batch_size"),
+			"Invalid batch size",
+			"batch_size must be at least 1.",
+		)
+	}
+	if !maxUnavailable.IsNull() && !maxUnavailable.IsUnknown() && (maxUnavailable.ValueInt64() < 1 || maxUnavailable.ValueInt64() > 100) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_unavailable_percent"),
+			"Invalid max_unavailable_percent",
+			"max_unavailable_percent must be between 1 and 100.",
+		)
+	}
+	if !pause.IsNull() && !pause.IsUnknown() {
+		if d, err := time.ParseDuration(pause.ValueString()); err != nil || d < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("pause"),
+				"Invalid pause",
+				fmt.Sprintf("pause must be a duration such as '10m', got %q.", pause.ValueString()),
+			)
+		}
+	}
+}
+
+// ModifyPlan plans restored, which becomes true once restore_trigger changes.
+func (r *rollingDrainResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	restored := types.BoolValue(false)
+	if !req.State.Raw.IsNull() {
+		var planned, current types.String
+		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("restore_trigger"), &planned)...)
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("restore_trigger"), &current)...)
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("restored"), &restored)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		switch {
+		case restored.ValueBool():
+		case planned.IsUnknown():
+			restored = types.BoolUnknown()
+		case !planned.Equal(current):
+			restored = types.BoolValue(true)
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("restored"), restored)...)
+}
+
+func (r *rollingDrainResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan rollingDrainResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Record the current states first, so that every host can be restored even if draining fails halfway.
+	previous := make(map[string]stateSnapshot, len(plan.Hostnames))
+	for _, hostname := range plan.Hostnames {
+		current, err := r.client.GetState(ctx, hostname)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error Reading roger state",
+				"Could not read roger state Hostname "+hostname+", roger_rolling_drain requires an existing entry: "+err.Error(),
+			)
+			return
+		}
+		// Alarm flags are not changed by the drain and therefore not recorded.
+		previous[hostname] = stateSnapshot{AppState: current.AppState, Message: current.Message}
+	}
+
+	resp.Diagnostics.Append(setPrivateJSON(ctx, resp.Private, previousStatesKey, previous)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ID = types.StringValue(hostsID(plan.Hostnames))
+	plan.Restored = types.BoolValue(false)
+
+	// The state is stored even if a batch fails, so that destroying the resource restores the hosts.
+	resp.Diagnostics.Append(r.inBatches(ctx, &plan, "Error Draining roger state", func(ctx context.Context, hostname string) error {
+		_, err := r.client.UpdateState(ctx, roger.StateInput{
+			Hostname: hostname,
+			AppState: roger.Ptr(drainingAppState),
+			Message:  plan.Message.ValueStringPointer(),
+		})
+		return err
+	})...)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Read keeps the state as is, the resource describes an operation rather than a roger entry.
+func (r *rollingDrainResource) Read(_ context.Context, _ resource.ReadRequest, _ *resource.ReadResponse) {
+}
+
+func (r *rollingDrainResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, prior rollingDrainResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.Restored = prior.Restored
+	switch {
+	case prior.Restored.ValueBool():
+		// Restored hosts are left alone until the resource is replaced.
+	case !plan.RestoreTrigger.Equal(prior.RestoreTrigger):
+		diags = r.restore(ctx, &plan, req.Private)
+		resp.Diagnostics.Append(diags...)
+		if diags.HasError() {
+			// Keep the previous trigger, so that the next apply plans the restore again.
+			plan.RestoreTrigger = prior.RestoreTrigger
+			break
+		}
+		plan.Restored = types.BoolValue(true)
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, previousStatesKey, nil)...)
+	case !plan.Message.Equal(prior.Message) && !plan.Message.IsNull():
+		errs := forEachHost(ctx, plan.Hostnames, defaultParallelism, func(ctx context.Context, hostname string) error {
+			_, err := r.client.UpdateState(ctx, roger.StateInput{
+				Hostname: hostname,
+				Message:  plan.Message.ValueStringPointer(),
+			})
+			return err
+		})
+		for hostname, err := range errs {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("message"),
+				"Error Updating roger state",
+				"Could not update message of "+hostname+", unexpected error: "+err.Error(),
+			)
+		}
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rollingDrainResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state rollingDrainResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || state.Restored.ValueBool() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.restore(ctx, &state, req.Private)...)
+}
+
+// restore returns the hosts to the states recorded on creation, in the same batches they were drained.
+func (r *rollingDrainResource) restore(ctx context.Context, m *rollingDrainResourceModel, private privateStateReader) diag.Diagnostics {
+	var previous map[string]stateSnapshot
+	found, diags := getPrivateJSON(ctx, private, previousStatesKey, &previous)
+	if diags.HasError() {
+		return diags
+	}
+	if !found {
+		tflog.Info(ctx, "No previous roger states recorded, leaving hosts unchanged", nil)
+		return diags
+	}
+
+	diags.Append(r.inBatches(ctx, m, "Error Restoring roger state", func(ctx context.Context, hostname string) error {
+		state, ok := previous[hostname]
+		if !ok {
+			return nil
+		}
+		input := state.input(hostname)
+		if m.Message.IsNull() {
+			input.Message = nil
+		}
+		_, err := r.client.UpdateState(ctx, input)
+		if roger.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})...)
+	return diags
+}
+
+// inBatches calls fn for the hosts of m batch by batch, waiting for the configured pause in between.
+// It stops after the first batch with a failed host and reports every failure as a diagnostic.
+func (r *rollingDrainResource) inBatches(ctx context.Context, m *rollingDrainResourceModel, summary string, fn func(context.Context, string) error) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	size := m.batchSize()
+	for start := 0; start < len(m.Hostnames); start += size {
+		if start > 0 && m.pause() > 0 {
+			tflog.Info(ctx, "Waiting before the next batch of hosts", map[string]any{
+				"pause": m.pause().String(),
+			})
+			select {
+			case <-ctx.Done():
+				diags.AddError(summary, "Interrupted while waiting for the next batch: "+ctx.Err().Error())
+				return diags
+			case <-time.After(m.pause()):
+			}
+		}
+
+		batch := m.Hostnames[start:min(start+size, len(m.Hostnames))]
+		tflog.Debug(ctx, "Processing batch of hosts", map[string]any{
+			"hostnames": batch,
+		})
+
+		errs := forEachHost(ctx, batch, int64(len(batch)), fn)
+		for _, hostname := range batch {
+			if err, ok := errs[hostname]; ok {
+				diags.AddAttributeError(
+					path.Root("hostnames"),
+					summary,
+					"Could not update state of "+hostname+", unexpected error: "+err.Error(),
+				)
+			}
+		}
+		if len(errs) > 0 {
+			diags.AddError(summary, "Stopped after a failed batch, the remaining hosts were not changed.")
+			return diags
+		}
+	}
+	return diags
+}
+
+func (r *rollingDrainResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*roger.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *roger.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
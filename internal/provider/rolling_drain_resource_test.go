@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/require"
+)
+
+func drainHostnames(n int) []string {
+	hostnames := make([]string, n)
+	for i := range hostnames {
+		hostnames[i] = fmt.Sprintf("web%03d.cern.ch", i)
+	}
+	return hostnames
+}
+
+func TestRollingDrainBatchSize(t *testing.T) {
+	tests := []struct {
+		name           string
+		hosts          int
+		batchSize      types.Int64
+		maxUnavailable types.Int64
+		want           int
+	}{
+		{"default", 10, types.Int64Null(), types.Int64Null(), 1},
+		{"batch size", 10, types.Int64Value(3), types.Int64Null(), 3},
+		{"percent", 10, types.Int64Null(), types.Int64Value(30), 3},
+		{"percent rounded down", 10, types.Int64Null(), types.Int64Value(25), 2},
+		{"percent at least one host", 3, types.Int64Null(), types.Int64Value(10), 1},
+		{"all hosts", 7, types.Int64Null(), types.Int64Value(100), 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := rollingDrainResourceModel{
+				Hostnames:             drainHostnames(tt.hosts),
+				BatchSize:             tt.batchSize,
+				MaxUnavailablePercent: tt.maxUnavailable,
+			}
+			require.Equal(t, tt.want, m.batchSize())
+		})
+	}
+}
+
+func TestRollingDrainInBatchesStopsAfterFailedBatch(t *testing.T) {
+	m := rollingDrainResourceModel{
+		Hostnames: drainHostnames(7),
+		BatchSize: types.Int64Value(2),
+		Pause:     types.StringValue("0s"),
+	}
+
+	var mu sync.Mutex
+	var called []string
+	diags := (&rollingDrainResource{}).inBatches(context.Background(), &m, "Error Draining roger state", func(_ context.Context, hostname string) error {
+		mu.Lock()
+		called = append(called, hostname)
+		mu.Unlock()
+		if hostname == "web003.cern.ch" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	require.True(t, diags.HasError())
+	// The second batch fails, the remaining batches are not started.
+	require.ElementsMatch(t, drainHostnames(4), called)
+	require.Len(t, diags.Errors(), 2)
+	require.Contains(t, diags.Errors()[0].Detail(), "web003.cern.ch")
+}
+
+func rollingDrainSchema(t *testing.T) tfsdk.Plan {
+	t.Helper()
+	var resp resource.SchemaResponse
+	(&rollingDrainResource{}).Schema(context.Background(), resource.SchemaRequest{}, &resp)
+	require.False(t, resp.Diagnostics.HasError())
+	return tfsdk.Plan{Schema: resp.Schema}
+}
+
+func TestRollingDrainModifyPlanRestored(t *testing.T) {
+	ctx := context.Background()
+	model := func(trigger types.String, restored types.Bool) rollingDrainResourceModel {
+		return rollingDrainResourceModel{
+			ID:             types.StringValue("id"),
+			Hostnames:      drainHostnames(2),
+			Pause:          types.StringValue("0s"),
+			RestoreTrigger: trigger,
+			Restored:       restored,
+		}
+	}
+
+	tests := []struct {
+		name  string
+		state *rollingDrainResourceModel
+		plan  rollingDrainResourceModel
+		want  types.Bool
+	}{
+		{
+			name: "create",
+			plan: model(types.StringNull(), types.BoolUnknown()),
+			want: types.BoolValue(false),
+		},
+		{
+			name:  "trigger unchanged",
+			state: roger.Ptr(model(types.StringValue("a"), types.BoolValue(false))),
+			plan:  model(types.StringValue("a"), types.BoolValue(false)),
+			want:  types.BoolValue(false),
+		},
+		{
+			name:  "trigger changed",
+			state: roger.Ptr(model(types.StringValue("a"), types.BoolValue(false))),
+			plan:  model(types.StringValue("b"), types.BoolValue(false)),
+			want:  types.BoolValue(true),
+		},
+		{
+			name:  "trigger set",
+			state: roger.Ptr(model(types.StringNull(), types.BoolValue(false))),
+			plan:  model(types.StringValue("a"), types.BoolValue(false)),
+			want:  types.BoolValue(true),
+		},
+		{
+			name:  "trigger unknown",
+			state: roger.Ptr(model(types.StringValue("a"), types.BoolValue(false))),
+			plan:  model(types.StringUnknown(), types.BoolValue(false)),
+			want:  types.BoolUnknown(),
+		},
+		{
+			name:  "already restored",
+			state: roger.Ptr(model(types.StringValue("b"), types.BoolValue(true))),
+			plan:  model(types.StringValue("c"), types.BoolValue(true)),
+			want:  types.BoolValue(true),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := rollingDrainSchema(t)
+			require.False(t, plan.Set(ctx, &tt.plan).HasError())
+
+			state := tfsdk.State{Schema: plan.Schema, Raw: tftypes.NewValue(plan.Schema.Type().TerraformType(ctx), nil)}
+			if tt.state != nil {
+				require.False(t, state.Set(ctx, tt.state).HasError())
+			}
+
+			req := resource.ModifyPlanRequest{Plan: plan, State: state}
+			resp := resource.ModifyPlanResponse{Plan: plan}
+			(&rollingDrainResource{}).ModifyPlan(ctx, req, &resp)
+			require.False(t, resp.Diagnostics.HasError())
+
+			var restored types.Bool
+			require.False(t, resp.Plan.GetAttribute(ctx, path.Root("restored"), &restored).HasError())
+			require.Equal(t, tt.want, restored)
+		})
+	}
+}
+
+func TestRollingDrainFailedRestoreKeepsTrigger(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeRoger(map[string]map[string]any{
+		"web000.cern.ch": {"hostname": "web000.cern.ch", "appstate": "production", "message": ""},
+		"web001.cern.ch": {"hostname": "web001.cern.ch", "appstate": "production", "message": ""},
+	})
+	server := testServer(t, newTestClient(t, fake))
+	r := &rollingDrainResource{}
+
+	apply := func(prior *rollingDrainResourceModel, planned rollingDrainResourceModel, private []byte) (rollingDrainResourceModel, *tfprotov6.ApplyResourceChangeResponse) {
+		t.Helper()
+		priorValue := resourceValue(t, r, nil)
+		if prior != nil {
+			priorValue = resourceValue(t, r, prior)
+		}
+		config := planned
+		config.ID = types.StringNull()
+		config.Restored = types.BoolNull()
+		resp, err := server.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+			TypeName:       "roger_rolling_drain",
+			PriorState:     priorValue,
+			PlannedState:   resourceValue(t, r, planned),
+			Config:         resourceValue(t, r, config),
+			PlannedPrivate: private,
+		})
+		require.NoError(t, err)
+
+		var state rollingDrainResourceModel
+		resourceModel(t, r, resp.NewState, &state)
+		return state, resp
+	}
+
+	planned := rollingDrainResourceModel{
+		ID:             types.StringUnknown(),
+		Hostnames:      drainHostnames(2),
+		Pause:          types.StringValue("0s"),
+		RestoreTrigger: types.StringValue("a"),
+		Restored:       types.BoolUnknown(),
+	}
+	drained, created := apply(nil, planned, nil)
+	require.Empty(t, created.Diagnostics)
+	require.Equal(t, "draining", fake.entry("web001.cern.ch")["appstate"])
+
+	// A restore that fails keeps the previous trigger, so that the next plan restores again.
+	fake.failing["web001.cern.ch"] = true
+	planned = drained
+	planned.RestoreTrigger = types.StringValue("b")
+	planned.Restored = types.BoolValue(true)
+	failed, resp := apply(&drained, planned, created.Private)
+	require.Len(t, resp.Diagnostics, 2)
+	require.Contains(t, resp.Diagnostics[0].Detail, "web001.cern.ch")
+	require.Equal(t, "production", fake.entry("web000.cern.ch")["appstate"])
+	require.Equal(t, "a", failed.RestoreTrigger.ValueString())
+	require.False(t, failed.Restored.ValueBool())
+
+	delete(fake.failing, "web001.cern.ch")
+	restored, resp := apply(&failed, planned, resp.Private)
+	require.Empty(t, resp.Diagnostics)
+	require.Equal(t, "b", restored.RestoreTrigger.ValueString())
+	require.True(t, restored.Restored.ValueBool())
+	require.Equal(t, "production", fake.entry("web000.cern.ch")["appstate"])
+	require.Equal(t, "production", fake.entry("web001.cern.ch")["appstate"])
+}
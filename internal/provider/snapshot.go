@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// stateSnapshot is a roger entry recorded in private state, so that it can be put back later.
+// Alarm flags and the expiry are only put back if they were recorded.
+type stateSnapshot struct {
+	AppState   string `json:"appstate"`
+	Message    string `json:"message"`
+	AppAlarmed *bool  `json:"app_alarmed,omitempty"`
+	HWAlarmed  *bool  `json:"hw_alarmed,omitempty"`
+	NCAlarmed  *bool  `json:"nc_alarmed,omitempty"`
+	OSAlarmed  *bool  `json:"os_alarmed,omitempty"`
+	// Expires is the expiry in RFC3339 format, empty if the entry did not expire.
+	Expires *string `json:"expires,omitempty"`
+}
+
+// snapshotOf records appstate, message, alarm flags and expiry of state.
+func snapshotOf(state *roger.State) stateSnapshot {
+	var expires string
+	if t, ok := state.ExpiresAt(); ok {
+		expires = t.Format(time.RFC3339)
+	}
+	return stateSnapshot{
+		AppState:   state.AppState,
+		Message:    state.Message,
+		AppAlarmed: roger.Ptr(state.AppAlarmed),
+		HWAlarmed:  roger.Ptr(state.HWAlarmed),
+		NCAlarmed:  roger.Ptr(state.NCAlarmed),
+		OSAlarmed:  roger.Ptr(state.OSAlarmed),
+		Expires:    &expires,
+	}
+}
+
+// input builds the client request putting the snapshot back. A recorded expiry that has
+// passed in the meantime is cleared rather than put back.
+func (s stateSnapshot) input(hostname string) roger.StateInput {
+	input := roger.StateInput{
+		Hostname:   hostname,
+		AppState:   roger.Ptr(s.AppState),
+		Message:    roger.Ptr(s.Message),
+		AppAlarmed: s.AppAlarmed,
+		HWAlarmed:  s.HWAlarmed,
+		NCAlarmed:  s.NCAlarmed,
+		OSAlarmed:  s.OSAlarmed,
+	}
+	if s.Expires != nil {
+		var expires roger.Expiry
+		if t, err := time.Parse(time.RFC3339, *s.Expires); err == nil && t.After(time.Now()) {
+			expires = roger.Expiry(t)
+		}
+		input.Expires = &expires
+	}
+	return input
+}
+
+// privateStateReader is implemented by the private state of read, update and delete requests.
+type privateStateReader interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// privateStateWriter is implemented by the private state of create and update responses.
+type privateStateWriter interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// setPrivateJSON stores v as JSON under key.
+func setPrivateJSON(ctx context.Context, private privateStateWriter, key string, v any) diag.Diagnostics {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError(
+			"Invalid private state",
+			"Could not encode "+key+": "+err.Error(),
+		)
+		return diags
+	}
+	return private.SetKey(ctx, key, raw)
+}
+
+// getPrivateJSON decodes the JSON stored under key into v. It returns false if the key is not set.
+func getPrivateJSON(ctx context.Context, private privateStateReader, key string, v any) (bool, diag.Diagnostics) {
+	raw, diags := private.GetKey(ctx, key)
+	if diags.HasError() || len(raw) == 0 {
+		return false, diags
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		diags.AddError(
+			"Invalid private state",
+			"Could not decode "+key+": "+err.Error(),
+		)
+		return false, diags
+	}
+	return true, diags
+}
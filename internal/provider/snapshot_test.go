@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	roger "roger/internal/client"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateSnapshotRoundTrip(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	snapshot := snapshotOf(&roger.State{
+		Hostname:   "host.cern.ch",
+		AppState:   "draining",
+		Message:    "intervention",
+		HWAlarmed:  true,
+		OSAlarmed:  true,
+		Expires:    strconv.FormatInt(expires.Unix(), 10),
+		AppAlarmed: false,
+	})
+
+	// The snapshot is stored as JSON in private state.
+	raw, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	var decoded stateSnapshot
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+
+	input := decoded.input("host.cern.ch")
+	require.Equal(t, "host.cern.ch", input.Hostname)
+	require.Equal(t, "draining", *input.AppState)
+	require.Equal(t, "intervention", *input.Message)
+	require.False(t, *input.AppAlarmed)
+	require.True(t, *input.HWAlarmed)
+	require.False(t, *input.NCAlarmed)
+	require.True(t, *input.OSAlarmed)
+	require.NotNil(t, input.Expires)
+	require.True(t, expires.Equal(time.Time(*input.Expires)))
+}
+
+func TestStateSnapshotExpiry(t *testing.T) {
+	// An entry without expiry clears the one set by the resource.
+	input := snapshotOf(&roger.State{AppState: "production"}).input("host.cern.ch")
+	require.NotNil(t, input.Expires)
+	require.True(t, time.Time(*input.Expires).IsZero())
+
+	// An expiry that has passed is not put back.
+	passed := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	input = snapshotOf(&roger.State{AppState: "draining", Expires: passed}).input("host.cern.ch")
+	require.NotNil(t, input.Expires)
+	require.True(t, time.Time(*input.Expires).IsZero())
+
+	// Snapshots without recorded alarm flags or expiry leave them unchanged.
+	input = stateSnapshot{AppState: "production"}.input("host.cern.ch")
+	require.Nil(t, input.Expires)
+	require.Nil(t, input.AppAlarmed)
+	require.Nil(t, input.HWAlarmed)
+}
@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ datasource.DataSource              = &stateDataSource{}
+	_ datasource.DataSourceWithConfigure = &stateDataSource{}
+)
+
+func NewStateDataSource() datasource.DataSource {
+	return &stateDataSource{}
+}
+
+type stateDataSourceModel struct {
+	ID              types.String `tfsdk:"id"`
+	Hostname        types.String `tfsdk:"hostname"`
+	AllowMissing    types.Bool   `tfsdk:"allow_missing"`
+	Message         types.String `tfsdk:"message"`
+	AppState        types.String `tfsdk:"appstate"`
+	AppAlarmed      types.Bool   `tfsdk:"app_alarmed"`
+	HWAlarmed       types.Bool   `tfsdk:"hw_alarmed"`
+	NCAlarmed       types.Bool   `tfsdk:"nc_alarmed"`
+	OSAlarmed       types.Bool   `tfsdk:"os_alarmed"`
+	ExpiresAt       types.String `tfsdk:"expires_at"`
+	LastUpdated     types.String `tfsdk:"last_updated"`
+	UpdatedBy       types.String `tfsdk:"updated_by"`
+	UpdateTime      types.String `tfsdk:"update_time"`
+	UpdateTimeDT    types.String `tfsdk:"update_time_dt"`
+	UpdatedByPuppet types.Bool   `tfsdk:"updated_by_puppet"`
+}
+
+// fromState copies the values reported by roger into the model. A nil state sets all values to null.
+func (m *stateDataSourceModel) fromState(state *roger.State) {
+	if state == nil {
+		m.ID = types.StringNull()
+		m.Message = types.StringNull()
+		m.AppState = types.StringNull()
+		m.AppAlarmed = types.BoolNull()
+		m.HWAlarmed = types.BoolNull()
+		m.NCAlarmed = types.BoolNull()
+		m.OSAlarmed = types.BoolNull()
+		m.ExpiresAt = types.StringNull()
+		m.LastUpdated = types.StringNull()
+		m.UpdatedBy = types.StringNull()
+		m.UpdateTime = types.StringNull()
+		m.UpdateTimeDT = types.StringNull()
+		m.UpdatedByPuppet = types.BoolNull()
+		return
+	}
+
+	m.ID = types.StringValue(state.Hostname)
+	if state.Message != "" {
+		m.Message = types.StringValue(state.Message)
+	} else {
+		m.Message = types.StringNull()
+	}
+	m.AppState = types.StringValue(state.AppState)
+	m.AppAlarmed = types.BoolValue(state.AppAlarmed)
+	m.HWAlarmed = types.BoolValue(state.HWAlarmed)
+	m.NCAlarmed = types.BoolValue(state.NCAlarmed)
+	m.OSAlarmed = types.BoolValue(state.OSAlarmed)
+	m.ExpiresAt = timeValue(state.ExpiresAt())
+	m.LastUpdated = timeValue(state.UpdatedAt())
+	m.UpdatedBy = types.StringValue(state.UpdatedBy)
+	m.UpdateTime = types.StringValue(state.UpdatedTime)
+	m.UpdateTimeDT = types.StringValue(state.UpdatedTimeDT)
+	m.UpdatedByPuppet = types.BoolValue(state.UpdatedByPuppet)
+}
+
+type stateDataSource struct {
+	client *roger.Client
+}
+
+func (d *stateDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_state"
+}
+
+func (d *stateDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Reads the roger state of a host.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Hostname of the roger entry, null if the host has no entry.",
+				Computed:    true,
+			},
+			"hostname": schema.StringAttribute{
+				Description: "Name of the host to read.",
+				Required:    true,
+			},
+			"allow_missing": schema.BoolAttribute{
+				Description: "Return null values instead of an error if the host has no roger entry. Defaults to false.",
+				Optional:    true,
+			},
+			"message": schema.StringAttribute{
+				Description: "Alert Message",
+				Computed:    true,
+			},
+			"appstate": schema.StringAttribute{
+				Description: "Application state of the host, e.g. 'production', 'draining' or 'quiesce'.",
+				Computed:    true,
+			},
+			"app_alarmed": schema.BoolAttribute{
+				Description: "Whether application alarms of the host are enabled.",
+				Computed:    true,
+			},
+			"hw_alarmed": schema.BoolAttribute{
+				Description: "Whether hardware alarms of the host are enabled.",
+				Computed:    true,
+			},
+			"nc_alarmed": schema.BoolAttribute{
+				Description: "Whether network connectivity alarms of the host are enabled.",
+				Computed:    true,
+			},
+			"os_alarmed": schema.BoolAttribute{
+				Description: "Whether operating system alarms of the host are enabled.",
+				Computed:    true,
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "Absolute expiry of the state reported by roger, in RFC3339 format.",
+				Computed:    true,
+			},
+			"last_updated": schema.StringAttribute{
+				Description: "Time of the last update of the state reported by roger, in RFC3339 format.",
+				Computed:    true,
+			},
+			"updated_by": schema.StringAttribute{
+				Description: "Account that last updated the state.",
+				Computed:    true,
+			},
+			"update_time": schema.StringAttribute{
+				Description: "Time of the last update of the state as reported by roger.",
+				Computed:    true,
+			},
+			"update_time_dt": schema.StringAttribute{
+				Description: "Date and time of the last update of the state as reported by roger.",
+				Computed:    true,
+			},
+			"updated_by_puppet": schema.BoolAttribute{
+				Description: "Whether the state was last updated by puppet.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *stateDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data stateDataSourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state, err := d.client.GetState(ctx, data.Hostname.ValueString())
+	if roger.IsNotFound(err) && data.AllowMissing.ValueBool() {
+		tflog.Debug(ctx, "roger state not found, returning null values", map[string]any{
+			"hostname": data.Hostname.ValueString(),
+		})
+		state, err = nil, nil
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading roger state",
+			"Could not read roger state Hostname "+data.Hostname.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	data.fromState(state)
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (d *stateDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*roger.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *roger.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/require"
+)
+
+// readStateDataSource reads the roger_state data source for hostname from fake.
+func readStateDataSource(t *testing.T, fake *fakeRoger, hostname string, allowMissing types.Bool) (stateDataSourceModel, datasource.ReadResponse) {
+	t.Helper()
+	ctx := context.Background()
+	d := &stateDataSource{client: newTestClient(t, fake)}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}
+	require.False(t, state.Set(ctx, &stateDataSourceModel{
+		Hostname:     types.StringValue(hostname),
+		AllowMissing: allowMissing,
+	}).HasError())
+
+	req := datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: state.Raw}}
+	resp := datasource.ReadResponse{State: state}
+	d.Read(ctx, req, &resp)
+
+	var got stateDataSourceModel
+	if !resp.Diagnostics.HasError() {
+		require.False(t, resp.State.Get(ctx, &got).HasError())
+	}
+	return got, resp
+}
+
+func TestStateDataSourceRead(t *testing.T) {
+	fake := newFakeRoger(map[string]map[string]any{
+		"host.cern.ch": {"hostname": "host.cern.ch", "appstate": "production", "message": "", "hw_alarmed": true, "updated_by": "admin"},
+	})
+
+	got, resp := readStateDataSource(t, fake, "host.cern.ch", types.BoolNull())
+	require.False(t, resp.Diagnostics.HasError(), "%v", resp.Diagnostics)
+	require.Equal(t, "host.cern.ch", got.ID.ValueString())
+	require.Equal(t, "production", got.AppState.ValueString())
+	require.True(t, got.Message.IsNull())
+	require.True(t, got.HWAlarmed.ValueBool())
+	require.False(t, got.AppAlarmed.ValueBool())
+	require.Equal(t, "admin", got.UpdatedBy.ValueString())
+}
+
+func TestStateDataSourceAllowMissing(t *testing.T) {
+	fake := newFakeRoger(nil)
+	fake.failing["broken.cern.ch"] = true
+
+	tests := []struct {
+		name         string
+		hostname     string
+		allowMissing types.Bool
+		wantError    bool
+	}{
+		{"missing", "gone.cern.ch", types.BoolNull(), true},
+		{"missing not allowed", "gone.cern.ch", types.BoolValue(false), true},
+		{"missing allowed", "gone.cern.ch", types.BoolValue(true), false},
+		{"server error", "broken.cern.ch", types.BoolValue(true), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, resp := readStateDataSource(t, fake, tt.hostname, tt.allowMissing)
+			if tt.wantError {
+				require.True(t, resp.Diagnostics.HasError())
+				require.Equal(t, "Error Reading roger state", resp.Diagnostics.Errors()[0].Summary())
+				return
+			}
+			require.False(t, resp.Diagnostics.HasError(), "%v", resp.Diagnostics)
+			require.Equal(t, tt.hostname, got.Hostname.ValueString())
+			require.True(t, got.ID.IsNull())
+			require.True(t, got.AppState.IsNull())
+			require.True(t, got.HWAlarmed.IsNull())
+			require.True(t, got.LastUpdated.IsNull())
+		})
+	}
+}
@@ -8,32 +8,150 @@ import (
 	"context"
 	"fmt"
 	roger "roger/internal/client"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 var (
-	_ resource.Resource                = &stateResource{}
-	_ resource.ResourceWithConfigure   = &stateResource{}
-	_ resource.ResourceWithImportState = &stateResource{}
+	_ resource.Resource                   = &stateResource{}
+	_ resource.ResourceWithConfigure      = &stateResource{}
+	_ resource.ResourceWithImportState    = &stateResource{}
+	_ resource.ResourceWithModifyPlan     = &stateResource{}
+	_ resource.ResourceWithValidateConfig = &stateResource{}
 )
 
+// Modes of on_destroy.
+const (
+	onDestroyDelete  = "delete"
+	onDestroyRestore = "restore"
+	onDestroySet     = "set"
+)
+
+// previousStateKey is the private state key holding the roger entry found when the resource was created,
+// or null if the host had no entry.
+const previousStateKey = "previous_state"
+
 func NewStateResource() resource.Resource {
 	return &stateResource{}
 }
 
 type stateResourceModel struct {
-	ID          types.String `tfsdk:"id"`
-	Hostname    types.String `tfsdk:"hostname"`
-	Message     types.String `tfsdk:"message"`
-	AppState    types.String `tfsdk:"appstate"`
-	LastUpdated types.String `tfsdk:"last_updated"`
+	ID                types.String `tfsdk:"id"`
+	Hostname          types.String `tfsdk:"hostname"`
+	Message           types.String `tfsdk:"message"`
+	AppState          types.String `tfsdk:"appstate"`
+	AppAlarmed        types.Bool   `tfsdk:"app_alarmed"`
+	HWAlarmed         types.Bool   `tfsdk:"hw_alarmed"`
+	NCAlarmed         types.Bool   `tfsdk:"nc_alarmed"`
+	OSAlarmed         types.Bool   `tfsdk:"os_alarmed"`
+	Expires           types.String `tfsdk:"expires"`
+	ExpiresAt         types.String `tfsdk:"expires_at"`
+	LastUpdated       types.String `tfsdk:"last_updated"`
+	UpdatedBy         types.String `tfsdk:"updated_by"`
+	UpdateTime        types.String `tfsdk:"update_time"`
+	UpdateTimeDT      types.String `tfsdk:"update_time_dt"`
+	UpdatedByPuppet   types.Bool   `tfsdk:"updated_by_puppet"`
+	OnDestroy         types.String `tfsdk:"on_destroy"`
+	OnDestroyAppState types.String `tfsdk:"on_destroy_appstate"`
+	OnDestroyMessage  types.String `tfsdk:"on_destroy_message"`
+	AdoptExisting     types.Bool   `tfsdk:"adopt_existing"`
+}
+
+// input builds the client request from the configuration, only attributes that are set are sent.
+// The message and expiry are also sent when they were removed from the configuration, so that
+// roger clears them. The expiry is only sent when it changed, as a duration would otherwise be
+// extended on every update. Unknown alarm flags are not sent.
+func (m *stateResourceModel) input(prior *stateResourceModel) (roger.StateInput, error) {
+	input := roger.StateInput{
+		Hostname:   m.Hostname.ValueString(),
+		AppState:   m.AppState.ValueStringPointer(),
+		AppAlarmed: knownBoolPointer(m.AppAlarmed),
+		HWAlarmed:  knownBoolPointer(m.HWAlarmed),
+		NCAlarmed:  knownBoolPointer(m.NCAlarmed),
+		OSAlarmed:  knownBoolPointer(m.OSAlarmed),
+	}
+	if !m.Message.IsNull() && !m.Message.IsUnknown() {
+		input.Message = m.Message.ValueStringPointer()
+	} else if prior != nil && !prior.Message.IsNull() {
+		input.Message = roger.Ptr("")
+	}
+
+	switch {
+	case !m.Expires.IsNull() && (prior == nil || !m.Expires.Equal(prior.Expires)):
+		now := time.Now()
+		expires, err := roger.ParseExpiry(m.Expires.ValueString(), now)
+		if err != nil {
+			return input, err
+		}
+		if !expires.After(now) {
+			return input, fmt.Errorf("expiry %s is in the past", expires.Format(time.RFC3339))
+		}
+		input.Expires = roger.Ptr(roger.Expiry(expires))
+	case m.Expires.IsNull() && prior != nil && !prior.Expires.IsNull():
+		input.Expires = roger.Ptr(roger.Expiry{})
+	}
+	return input, nil
+}
+
+// expiryElapsed reports whether a duration given as expires has passed according to roger. An absolute
+// timestamp that has passed is kept, as it cannot be applied again.
+func (m *stateResourceModel) expiryElapsed(state *roger.State, now time.Time) bool {
+	if m.Expires.IsNull() || isAbsoluteExpiry(m.Expires.ValueString()) {
+		return false
+	}
+	expires, ok := state.ExpiresAt()
+	return !ok || !expires.After(now)
+}
+
+// timeValue formats a point in time reported by roger as RFC3339, or null if it is not set.
+func timeValue(t time.Time, ok bool) types.String {
+	if !ok {
+		return types.StringNull()
+	}
+	return types.StringValue(t.Format(time.RFC3339))
+}
+
+// knownBoolPointer returns nil for null and unknown values.
+func knownBoolPointer(v types.Bool) *bool {
+	if v.IsNull() || v.IsUnknown() {
+		return nil
+	}
+	return v.ValueBoolPointer()
+}
+
+// fromState copies the values reported by roger into the model.
+func (m *stateResourceModel) fromState(state *roger.State) {
+	m.ID = types.StringValue(state.Hostname)
+	m.Hostname = types.StringValue(state.Hostname)
+	m.AppState = types.StringValue(state.AppState)
+	if state.Message != "" {
+		m.Message = types.StringValue(state.Message)
+	} else {
+		m.Message = types.StringNull()
+	}
+	m.AppAlarmed = types.BoolValue(state.AppAlarmed)
+	m.HWAlarmed = types.BoolValue(state.HWAlarmed)
+	m.NCAlarmed = types.BoolValue(state.NCAlarmed)
+	m.OSAlarmed = types.BoolValue(state.OSAlarmed)
+	m.ExpiresAt = timeValue(state.ExpiresAt())
+	m.LastUpdated = timeValue(state.UpdatedAt())
+	m.UpdatedBy = types.StringValue(state.UpdatedBy)
+	m.UpdateTime = types.StringValue(state.UpdatedTime)
+	m.UpdateTimeDT = types.StringValue(state.UpdatedTimeDT)
+	m.UpdatedByPuppet = types.BoolValue(state.UpdatedByPuppet)
 }
 
 type stateResource struct {
@@ -49,28 +167,117 @@ func (r *stateResource) Schema(_ context.Context, _ resource.SchemaRequest, resp
 		Description: "Manages an roger state.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
-				Description: "Numeric identifier of the state.",
+				Description: "Hostname of the roger entry, used to import existing entries.",
 				Computed:    true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
 			"last_updated": schema.StringAttribute{
-				Description: "Timestamp of the last Terraform update of the state.",
+				Description: "Time of the last update of the state reported by roger, in RFC3339 format.",
+				Computed:    true,
+			},
+			"updated_by": schema.StringAttribute{
+				Description: "Account that last updated the state.",
+				Computed:    true,
+			},
+			"update_time": schema.StringAttribute{
+				Description: "Time of the last update of the state as reported by roger.",
+				Computed:    true,
+			},
+			"update_time_dt": schema.StringAttribute{
+				Description: "Date and time of the last update of the state as reported by roger.",
+				Computed:    true,
+			},
+			"updated_by_puppet": schema.BoolAttribute{
+				Description: "Whether the state was last updated by puppet.",
 				Computed:    true,
 			},
 			"hostname": schema.StringAttribute{
-				Description: "Name of the hostname that belongs to the state.",
+				Description: "Name of the hostname that belongs to the state. Changing it replaces the resource.",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"message": schema.StringAttribute{
 				Description: "Alert Message",
 				Optional:    true,
 			},
 			"appstate": schema.StringAttribute{
-				Description: "Set to 'production', 'draining' or 'quiesce' which are current valid states. Has no effect on alarm status, but may be used to set application state.",
+				Description: "Set to 'production', 'draining' or 'quiesce' which are current valid states, or another appstate known to the roger server or listed in allowed_appstates of the provider. Has no effect on alarm status, but may be used to set application state.",
 				Required:    true,
 			},
+			"expires": schema.StringAttribute{
+				Description: "When roger reverts the state, either as an RFC3339 timestamp such as \"2025-03-01T18:00:00Z\" " +
+					"or as a duration such as \"4h\" counted from the time of apply. Once a duration has passed, a change is planned to apply it again. " +
+					"A timestamp that has passed is kept and not sent to roger again.",
+				Optional: true,
+				Validators: []validator.String{
+					expiryValidator{},
+				},
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "Absolute expiry of the state reported by roger, in RFC3339 format.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					expiresAtModifier{},
+				},
+			},
+			"adopt_existing": schema.BoolAttribute{
+				Description: "Update the roger entry of the host in place if it already exists, instead of failing. Defaults to false. " +
+					"With the default on_destroy = 'delete', destroying the resource deletes the adopted entry, set on_destroy = 'restore' to put it back instead.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"on_destroy": schema.StringAttribute{
+				Description: "What happens to the roger entry when the resource is destroyed: 'delete' removes it, 'restore' puts back the entry found " +
+					"when the resource was created (or removes it if there was none), 'set' applies on_destroy_appstate and on_destroy_message. Defaults to 'delete'.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(onDestroyDelete),
+			},
+			"on_destroy_appstate": schema.StringAttribute{
+				Description: "Appstate set when the resource is destroyed with on_destroy = 'set'.",
+				Optional:    true,
+			},
+			"on_destroy_message": schema.StringAttribute{
+				Description: "Alert Message set when the resource is destroyed with on_destroy = 'set'. If not set, the message of roger is kept.",
+				Optional:    true,
+			},
+			"app_alarmed": schema.BoolAttribute{
+				Description: "Whether application alarms of the host are enabled. Set to false to mask them. If not set, the value of roger is kept, which enables alarms by default.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"hw_alarmed": schema.BoolAttribute{
+				Description: "Whether hardware alarms of the host are enabled. Set to false to mask them. If not set, the value of roger is kept, which enables alarms by default.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"nc_alarmed": schema.BoolAttribute{
+				Description: "Whether network connectivity alarms of the host are enabled. Set to false to mask them. If not set, the value of roger is kept, which enables alarms by default.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"os_alarmed": schema.BoolAttribute{
+				Description: "Whether operating system alarms of the host are enabled. Set to false to mask them. If not set, the value of roger is kept, which enables alarms by default.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
 		},
 	}
 }
@@ -83,7 +290,60 @@ func (r *stateResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	state, err := r.client.CreateState(plan.Hostname.ValueString(), plan.Message.ValueString(), plan.AppState.ValueString())
+	var config stateResourceModel
+	diags = req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	input, err := config.input(nil)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("expires"),
+			"Invalid roger expiry",
+			"Could not create state: "+err.Error(),
+		)
+		return
+	}
+
+	// Record the entry found before, so that on_destroy = "restore" can put it back.
+	var previous *stateSnapshot
+	current, err := r.client.GetState(ctx, input.Hostname)
+	switch {
+	case err == nil:
+		previous = roger.Ptr(snapshotOf(current))
+	case !roger.IsNotFound(err):
+		resp.Diagnostics.AddError(
+			"Error Reading roger state",
+			"Could not read roger state Hostname "+input.Hostname+": "+err.Error(),
+		)
+		return
+	}
+	if previous != nil && !plan.AdoptExisting.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("hostname"),
+			"roger state already exists",
+			fmt.Sprintf("%s already has a roger entry. Either import it into the Terraform state, replacing <name> with the name of this resource:\n\n"+
+				"  terraform import roger_state.<name> %s\n\n"+
+				"or set adopt_existing = true to update the existing entry in place.", input.Hostname, input.Hostname),
+		)
+		return
+	}
+	resp.Diagnostics.Append(setPrivateJSON(ctx, resp.Private, previousStateKey, previous)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state *roger.State
+	if previous != nil {
+		tflog.Info(ctx, "Adopting existing roger state", map[string]any{
+			"hostname": input.Hostname,
+		})
+		state, err = r.client.UpdateState(ctx, input)
+	} else {
+		state, err = r.client.CreateState(ctx, input)
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error creating state",
@@ -92,15 +352,7 @@ func (r *stateResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	plan.ID = types.StringValue(state.Hostname)
-	plan.Hostname = types.StringValue(state.Hostname)
-	plan.AppState = types.StringValue(state.AppState)
-	if state.Message != "" {
-		plan.Message = types.StringValue(state.Message)
-	} else {
-		plan.Message = types.StringNull()
-	}
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+	plan.fromState(state)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -117,39 +369,59 @@ func (r *stateResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	state, err := r.client.GetState(readState.Hostname.ValueString())
+	// After an import only the ID, which is the hostname, is known.
+	hostname := readState.Hostname.ValueString()
+	if hostname == "" {
+		hostname = readState.ID.ValueString()
+	}
+
+	state, err := r.client.GetState(ctx, hostname)
+	if roger.IsNotFound(err) {
+		tflog.Warn(ctx, "roger state not found, removing from Terraform state", map[string]any{
+			"hostname": hostname,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error Reading roger state",
-			"Could not read roger state ID "+readState.ID.ValueString()+": "+err.Error(),
+			"Could not read roger state Hostname "+hostname+": "+err.Error(),
 		)
 		return
 	}
 
-	validStates := []string{"production", "draining", "quiesce"}
-	isValid := false
-	for _, v := range validStates {
-		if state.AppState == v {
-			isValid = true
-			break
-		}
-	}
-	if !isValid {
-		resp.Diagnostics.AddError(
-			"Invalid Roger appstate",
-			"Roger cannot use "+state.Hostname+" because it is not in [production, draining, quiesce].",
+	// The appstate is only checked to warn about drift, a refresh does not fail if the catalogue cannot be read.
+	appStates, err := r.client.AppStates(ctx)
+	if err != nil {
+		tflog.Warn(ctx, "Could not read roger appstates, skipping appstate check", map[string]any{
+			"error": err.Error(),
+		})
+	} else if !slices.Contains(appStates, state.AppState) {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("appstate"),
+			"Unknown roger appstate",
+			fmt.Sprintf("roger reports appstate %q for %s, which is not one of %s. "+
+				"Terraform will plan to change it back to the configured value.",
+				state.AppState, state.Hostname, strings.Join(appStates, ", ")),
 		)
-		return
 	}
 
-	readState.ID = types.StringValue(state.Hostname)
-	readState.Hostname = types.StringValue(state.Hostname)
-	readState.AppState = types.StringValue(state.AppState)
-	if state.Message != "" {
-		readState.Message = types.StringValue(state.Message)
-	} else {
-		readState.Message = types.StringNull()
+	readState.fromState(state)
+	if readState.OnDestroy.IsNull() {
+		readState.OnDestroy = types.StringValue(onDestroyDelete)
+	}
+	if readState.AdoptExisting.IsNull() {
+		readState.AdoptExisting = types.BoolValue(false)
+	}
+	if readState.expiryElapsed(state, time.Now()) {
+		// roger has reverted the state, forget the expiry so that the configured one is applied again.
+		tflog.Info(ctx, "roger state expired", map[string]any{
+			"hostname": state.Hostname,
+		})
+		readState.Expires = types.StringNull()
 	}
+
 	diags = resp.State.Set(ctx, &readState)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -157,6 +429,88 @@ func (r *stateResource) Read(ctx context.Context, req resource.ReadRequest, resp
 	}
 }
 
+// ModifyPlan rejects appstates that are not allowed before anything is written to roger.
+func (r *stateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	for _, attr := range []string{"appstate", "on_destroy_appstate"} {
+		var appState types.String
+		diags := req.Plan.GetAttribute(ctx, path.Root(attr), &appState)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if appState.IsUnknown() || appState.IsNull() {
+			continue
+		}
+
+		appStates, err := r.client.AppStates(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error Reading roger appstates",
+				"Could not read the appstates accepted by roger: "+err.Error(),
+			)
+			return
+		}
+		if !slices.Contains(appStates, appState.ValueString()) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(attr),
+				"Invalid roger appstate",
+				fmt.Sprintf("%s %q is not one of %s. Sites with custom appstates can set allowed_appstates in the provider configuration.",
+					attr, appState.ValueString(), strings.Join(appStates, ", ")),
+			)
+		}
+	}
+}
+
+func (r *stateResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var onDestroy, onDestroyAppState, onDestroyMessage types.String
+	var adoptExisting types.Bool
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("adopt_existing"), &adoptExisting)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("on_destroy"), &onDestroy)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("on_destroy_appstate"), &onDestroyAppState)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("on_destroy_message"), &onDestroyMessage)...)
+	if resp.Diagnostics.HasError() || onDestroy.IsUnknown() {
+		return
+	}
+
+	mode := onDestroy.ValueString()
+	switch mode {
+	case "", onDestroyDelete, onDestroyRestore:
+		if !onDestroyAppState.IsNull() || !onDestroyMessage.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("on_destroy"),
+				"Unused on_destroy settings",
+				"on_destroy_appstate and on_destroy_message are only used with on_destroy = \"set\".",
+			)
+		}
+		if mode != onDestroyRestore && adoptExisting.ValueBool() {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("adopt_existing"),
+				"Adopted roger entry is deleted on destroy",
+				"With adopt_existing = true and on_destroy = \"delete\", destroying the resource deletes the existing roger entry, "+
+					"e.g. one created by puppet. Set on_destroy = \"restore\" to put the adopted entry back instead.",
+			)
+		}
+	case onDestroySet:
+		if onDestroyAppState.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("on_destroy_appstate"),
+				"Missing on_destroy_appstate",
+				"on_destroy = \"set\" requires on_destroy_appstate.",
+			)
+		}
+	default:
+		resp.Diagnostics.AddAttributeError(
+			path.Root("on_destroy"),
+			"Invalid on_destroy",
+			fmt.Sprintf("on_destroy must be one of %q, %q or %q, got %q.", onDestroyDelete, onDestroyRestore, onDestroySet, mode),
+		)
+	}
+}
+
 func (r *stateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var plan stateResourceModel
 	diags := req.Plan.Get(ctx, &plan)
@@ -165,7 +519,26 @@ func (r *stateResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	_, err := r.client.UpdateState(plan.Hostname.ValueString(), plan.Message.ValueString(), plan.AppState.ValueString())
+	var config, prior stateResourceModel
+	diags = req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	input, err := config.input(&prior)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("expires"),
+			"Invalid roger expiry",
+			"Could not update state: "+err.Error(),
+		)
+		return
+	}
+
+	_, err = r.client.UpdateState(ctx, input)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error Updating roger state",
@@ -174,7 +547,7 @@ func (r *stateResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	statePtr, err := r.client.GetState(plan.Hostname.ValueString())
+	statePtr, err := r.client.GetState(ctx, plan.Hostname.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error Reading roger state",
@@ -183,15 +556,7 @@ func (r *stateResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	plan.ID = types.StringValue(statePtr.Hostname)
-	plan.Hostname = types.StringValue(statePtr.Hostname)
-	plan.AppState = types.StringValue(statePtr.AppState)
-	if statePtr.Message != "" {
-		plan.Message = types.StringValue(statePtr.Message)
-	} else {
-		plan.Message = types.StringNull()
-	}
-	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+	plan.fromState(statePtr)
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -208,7 +573,50 @@ func (r *stateResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
-	err := r.client.DeleteState(state.Hostname.ValueString())
+	hostname := state.Hostname.ValueString()
+	switch state.OnDestroy.ValueString() {
+	case onDestroyRestore:
+		var previous *stateSnapshot
+		found, diags := getPrivateJSON(ctx, req.Private, previousStateKey, &previous)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if !found {
+			resp.Diagnostics.AddWarning(
+				"No previous roger state recorded",
+				"The roger entry of "+hostname+" found before the resource was created is unknown, e.g. because the resource was imported. "+
+					"The entry is left unchanged.",
+			)
+			return
+		}
+		if previous != nil {
+			_, err := r.client.UpdateState(ctx, previous.input(hostname))
+			if err != nil && !roger.IsNotFound(err) {
+				resp.Diagnostics.AddError(
+					"Error Restoring roger state",
+					"Could not restore previous state, unexpected error: "+err.Error(),
+				)
+			}
+			return
+		}
+		// The host had no entry before, restoring means deleting it.
+	case onDestroySet:
+		_, err := r.client.UpdateState(ctx, roger.StateInput{
+			Hostname: hostname,
+			AppState: state.OnDestroyAppState.ValueStringPointer(),
+			Message:  state.OnDestroyMessage.ValueStringPointer(),
+		})
+		if err != nil && !roger.IsNotFound(err) {
+			resp.Diagnostics.AddError(
+				"Error Updating roger state",
+				"Could not set state on destroy, unexpected error: "+err.Error(),
+			)
+		}
+		return
+	}
+
+	err := r.client.DeleteState(ctx, hostname)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error Deleting roger state",
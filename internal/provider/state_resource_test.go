@@ -0,0 +1,365 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	roger "roger/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateExpiryElapsed(t *testing.T) {
+	now := time.Now()
+	future := &roger.State{Expires: strconv.FormatInt(now.Add(time.Hour).Unix(), 10)}
+	reverted := &roger.State{}
+
+	tests := []struct {
+		name    string
+		expires types.String
+		state   *roger.State
+		want    bool
+	}{
+		{"no expiry", types.StringNull(), reverted, false},
+		{"duration pending", types.StringValue("4h"), future, false},
+		{"duration elapsed", types.StringValue("4h"), reverted, true},
+		{"timestamp elapsed", types.StringValue("2020-01-01T00:00:00Z"), reverted, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := stateResourceModel{Expires: tt.expires}
+			require.Equal(t, tt.want, m.expiryElapsed(tt.state, now))
+		})
+	}
+}
+
+func TestStateInputKeepsElapsedTimestamp(t *testing.T) {
+	prior := stateResourceModel{
+		Hostname: types.StringValue("host.cern.ch"),
+		AppState: types.StringValue("draining"),
+		Expires:  types.StringValue("2020-01-01T00:00:00Z"),
+	}
+	config := prior
+	config.AppState = types.StringValue("production")
+
+	// Read keeps the elapsed timestamp, so later applies must not send it again.
+	require.False(t, prior.expiryElapsed(&roger.State{}, time.Now()))
+	input, err := config.input(&prior)
+	require.NoError(t, err)
+	require.Nil(t, input.Expires)
+	require.Equal(t, "production", *input.AppState)
+
+	// A new timestamp that has passed is still rejected.
+	config.Expires = types.StringValue("2021-01-01T00:00:00Z")
+	_, err = config.input(&prior)
+	require.ErrorContains(t, err, "in the past")
+}
+
+// stateConfig builds the configuration of a roger_state resource from m.
+func stateConfig(t *testing.T, m stateResourceModel) tfsdk.Config {
+	t.Helper()
+	ctx := context.Background()
+
+	var schemaResp resource.SchemaResponse
+	(&stateResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+
+	state := tfsdk.State{Schema: schemaResp.Schema}
+	require.False(t, state.Set(ctx, &m).HasError())
+	return tfsdk.Config{Schema: schemaResp.Schema, Raw: state.Raw}
+}
+
+func TestStateValidateConfigOnDestroy(t *testing.T) {
+	null := types.StringNull()
+	value := types.StringValue
+
+	tests := []struct {
+		name      string
+		onDestroy types.String
+		appState  types.String
+		message   types.String
+		wantError string
+	}{
+		{"default", null, null, null, ""},
+		{"delete", value(onDestroyDelete), null, null, ""},
+		{"restore", value(onDestroyRestore), null, null, ""},
+		{"set", value(onDestroySet), value("production"), null, ""},
+		{"set with message", value(onDestroySet), value("production"), value("done"), ""},
+		{"unknown mode", types.StringUnknown(), value("production"), null, ""},
+		{"delete with appstate", value(onDestroyDelete), value("production"), null, "Unused on_destroy settings"},
+		{"default with message", null, null, value("done"), "Unused on_destroy settings"},
+		{"restore with message", value(onDestroyRestore), null, value("done"), "Unused on_destroy settings"},
+		{"set without appstate", value(onDestroySet), null, value("done"), "Missing on_destroy_appstate"},
+		{"invalid mode", value("keep"), null, null, "Invalid on_destroy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := stateConfig(t, stateResourceModel{
+				Hostname:          value("host.cern.ch"),
+				AppState:          value("draining"),
+				OnDestroy:         tt.onDestroy,
+				OnDestroyAppState: tt.appState,
+				OnDestroyMessage:  tt.message,
+			})
+
+			var resp resource.ValidateConfigResponse
+			(&stateResource{}).ValidateConfig(context.Background(), resource.ValidateConfigRequest{Config: config}, &resp)
+
+			if tt.wantError == "" {
+				require.False(t, resp.Diagnostics.HasError(), "%v", resp.Diagnostics)
+				return
+			}
+			require.Len(t, resp.Diagnostics.Errors(), 1)
+			require.Equal(t, tt.wantError, resp.Diagnostics.Errors()[0].Summary())
+		})
+	}
+}
+
+func TestStateValidateConfigWarnsAboutDeletingAdoptedEntry(t *testing.T) {
+	for _, tt := range []struct {
+		onDestroy types.String
+		warn      bool
+	}{
+		{types.StringNull(), true},
+		{types.StringValue(onDestroyDelete), true},
+		{types.StringValue(onDestroyRestore), false},
+	} {
+		config := stateConfig(t, stateResourceModel{
+			Hostname:      types.StringValue("host.cern.ch"),
+			AppState:      types.StringValue("draining"),
+			OnDestroy:     tt.onDestroy,
+			AdoptExisting: types.BoolValue(true),
+		})
+
+		var resp resource.ValidateConfigResponse
+		(&stateResource{}).ValidateConfig(context.Background(), resource.ValidateConfigRequest{Config: config}, &resp)
+		require.False(t, resp.Diagnostics.HasError())
+		require.Equal(t, tt.warn, resp.Diagnostics.WarningsCount() == 1, "on_destroy %s", tt.onDestroy)
+	}
+}
+
+// newTestClient returns a client sending its requests to handler, without Kerberos authentication.
+func newTestClient(t *testing.T, handler http.Handler) *roger.Client {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+
+	base, err := url.Parse(srv.URL + "/roger/v1/")
+	require.NoError(t, err)
+	return &roger.Client{
+		HTTPClient: spnego.NewClient(nil, srv.Client(), ""),
+		BaseURL:    base,
+	}
+}
+
+// existingStateHandler serves the roger entry of host.cern.ch and fails on any write.
+func existingStateHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != "/roger/v1/state/host.cern.ch/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hostname": "host.cern.ch", "appstate": "production", "message": "puppet", "hw_alarmed": true}`))
+	})
+}
+
+func TestStateCreateFailsForExistingEntry(t *testing.T) {
+	ctx := context.Background()
+	r := &stateResource{client: newTestClient(t, existingStateHandler(t))}
+
+	config := stateConfig(t, stateResourceModel{
+		Hostname:      types.StringValue("host.cern.ch"),
+		AppState:      types.StringValue("draining"),
+		OnDestroy:     types.StringValue(onDestroyDelete),
+		AdoptExisting: types.BoolValue(false),
+	})
+	req := resource.CreateRequest{
+		Config: config,
+		Plan:   tfsdk.Plan{Schema: config.Schema, Raw: config.Raw},
+	}
+	resp := resource.CreateResponse{
+		State: tfsdk.State{Schema: config.Schema, Raw: tftypes.NewValue(config.Schema.Type().TerraformType(ctx), nil)},
+	}
+	r.Create(ctx, req, &resp)
+
+	require.Len(t, resp.Diagnostics.Errors(), 1)
+	diag := resp.Diagnostics.Errors()[0]
+	require.Equal(t, "roger state already exists", diag.Summary())
+	require.Contains(t, diag.Detail(), "terraform import roger_state.<name> host.cern.ch")
+	require.Contains(t, diag.Detail(), "adopt_existing = true")
+	require.True(t, resp.State.Raw.IsNull())
+}
+
+func TestStateReadAfterImportUsesID(t *testing.T) {
+	ctx := context.Background()
+	r := &stateResource{client: newTestClient(t, existingStateHandler(t))}
+
+	// terraform import only sets the ID.
+	imported := stateConfig(t, stateResourceModel{ID: types.StringValue("host.cern.ch")})
+	state := tfsdk.State{Schema: imported.Schema, Raw: imported.Raw}
+
+	resp := resource.ReadResponse{State: state}
+	r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+	require.False(t, resp.Diagnostics.HasError(), "%v", resp.Diagnostics)
+
+	var got stateResourceModel
+	require.False(t, resp.State.Get(ctx, &got).HasError())
+	require.Equal(t, "host.cern.ch", got.ID.ValueString())
+	require.Equal(t, "host.cern.ch", got.Hostname.ValueString())
+	require.Equal(t, "production", got.AppState.ValueString())
+	require.Equal(t, "puppet", got.Message.ValueString())
+	require.True(t, got.HWAlarmed.ValueBool())
+	require.Equal(t, onDestroyDelete, got.OnDestroy.ValueString())
+	require.False(t, got.AdoptExisting.ValueBool())
+}
+
+func TestStateHostnameChangeRequiresReplace(t *testing.T) {
+	ctx := context.Background()
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	require.NoError(t, err)
+
+	prior := stateResourceModel{
+		ID:              types.StringValue("old.cern.ch"),
+		Hostname:        types.StringValue("old.cern.ch"),
+		AppState:        types.StringValue("draining"),
+		AppAlarmed:      types.BoolValue(true),
+		HWAlarmed:       types.BoolValue(true),
+		NCAlarmed:       types.BoolValue(true),
+		OSAlarmed:       types.BoolValue(true),
+		LastUpdated:     types.StringValue("2025-01-01T00:00:00Z"),
+		UpdatedBy:       types.StringValue("admin"),
+		UpdateTime:      types.StringValue("1735689600"),
+		UpdateTimeDT:    types.StringValue("2025-01-01 00:00:00"),
+		UpdatedByPuppet: types.BoolValue(false),
+		OnDestroy:       types.StringValue(onDestroyRestore),
+		AdoptExisting:   types.BoolValue(true),
+	}
+	proposed := prior
+	proposed.Hostname = types.StringValue("new.cern.ch")
+	config := stateResourceModel{
+		Hostname:      proposed.Hostname,
+		AppState:      proposed.AppState,
+		OnDestroy:     proposed.OnDestroy,
+		AdoptExisting: proposed.AdoptExisting,
+	}
+
+	dynamicValue := func(m stateResourceModel) *tfprotov6.DynamicValue {
+		raw := stateConfig(t, m).Raw
+		v, err := tfprotov6.NewDynamicValue(raw.Type(), raw)
+		require.NoError(t, err)
+		return &v
+	}
+	resp, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         "roger_state",
+		PriorState:       dynamicValue(prior),
+		ProposedNewState: dynamicValue(proposed),
+		Config:           dynamicValue(config),
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.Diagnostics)
+	require.Equal(t, []*tftypes.AttributePath{tftypes.NewAttributePath().WithAttributeName("hostname")}, resp.RequiresReplace)
+}
+
+func TestStateInputSendsConfiguredAlarmFlags(t *testing.T) {
+	config := stateResourceModel{
+		Hostname:   types.StringValue("host.cern.ch"),
+		AppState:   types.StringValue("draining"),
+		AppAlarmed: types.BoolUnknown(),
+		HWAlarmed:  types.BoolValue(false),
+		NCAlarmed:  types.BoolNull(),
+		OSAlarmed:  types.BoolValue(true),
+	}
+
+	input, err := config.input(nil)
+	require.NoError(t, err)
+	require.Nil(t, input.AppAlarmed)
+	require.Equal(t, roger.Ptr(false), input.HWAlarmed)
+	require.Nil(t, input.NCAlarmed)
+	require.Equal(t, roger.Ptr(true), input.OSAlarmed)
+}
+
+func TestStateReadReportsAlarmDrift(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeRoger(map[string]map[string]any{
+		"host.cern.ch": {"hostname": "host.cern.ch", "appstate": "draining", "hw_alarmed": true, "os_alarmed": false},
+	})
+	r := &stateResource{client: newTestClient(t, fake)}
+
+	prior := stateConfig(t, stateResourceModel{
+		ID:         types.StringValue("host.cern.ch"),
+		Hostname:   types.StringValue("host.cern.ch"),
+		AppState:   types.StringValue("draining"),
+		AppAlarmed: types.BoolValue(false),
+		HWAlarmed:  types.BoolValue(false),
+		NCAlarmed:  types.BoolValue(false),
+		OSAlarmed:  types.BoolValue(false),
+	})
+	state := tfsdk.State{Schema: prior.Schema, Raw: prior.Raw}
+	resp := resource.ReadResponse{State: state}
+	r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+	require.False(t, resp.Diagnostics.HasError(), "%v", resp.Diagnostics)
+
+	var got stateResourceModel
+	require.False(t, resp.State.Get(ctx, &got).HasError())
+	require.True(t, got.HWAlarmed.ValueBool())
+	require.False(t, got.OSAlarmed.ValueBool())
+}
+
+func TestStateReadRemovesMissingEntry(t *testing.T) {
+	ctx := context.Background()
+	r := &stateResource{client: newTestClient(t, newFakeRoger(nil))}
+
+	prior := stateConfig(t, stateResourceModel{
+		ID:       types.StringValue("gone.cern.ch"),
+		Hostname: types.StringValue("gone.cern.ch"),
+		AppState: types.StringValue("draining"),
+	})
+	state := tfsdk.State{Schema: prior.Schema, Raw: prior.Raw}
+	resp := resource.ReadResponse{State: state}
+	r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+
+	require.False(t, resp.Diagnostics.HasError(), "%v", resp.Diagnostics)
+	require.True(t, resp.State.Raw.IsNull())
+}
+
+func TestStateReadFailsOnServerError(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeRoger(nil)
+	fake.failing["host.cern.ch"] = true
+	r := &stateResource{client: newTestClient(t, fake)}
+
+	prior := stateConfig(t, stateResourceModel{
+		ID:       types.StringValue("host.cern.ch"),
+		Hostname: types.StringValue("host.cern.ch"),
+		AppState: types.StringValue("draining"),
+	})
+	state := tfsdk.State{Schema: prior.Schema, Raw: prior.Raw}
+	resp := resource.ReadResponse{State: state}
+	r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+
+	require.True(t, resp.Diagnostics.HasError())
+	require.False(t, resp.State.Raw.IsNull())
+}
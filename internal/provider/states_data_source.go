@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	roger "roger/internal/client"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSource              = &statesDataSource{}
+	_ datasource.DataSourceWithConfigure = &statesDataSource{}
+)
+
+func NewStatesDataSource() datasource.DataSource {
+	return &statesDataSource{}
+}
+
+type statesDataSourceModel struct {
+	AppState        types.String                `tfsdk:"appstate"`
+	HostnamePattern types.String                `tfsdk:"hostname_pattern"`
+	AppAlarmed      types.Bool                  `tfsdk:"app_alarmed"`
+	HWAlarmed       types.Bool                  `tfsdk:"hw_alarmed"`
+	NCAlarmed       types.Bool                  `tfsdk:"nc_alarmed"`
+	OSAlarmed       types.Bool                  `tfsdk:"os_alarmed"`
+	Hostnames       []string                    `tfsdk:"hostnames"`
+	States          map[string]stateRecordModel `tfsdk:"states"`
+}
+
+// stateRecordModel is a single roger entry returned by roger_states.
+type stateRecordModel struct {
+	Hostname        types.String `tfsdk:"hostname"`
+	Message         types.String `tfsdk:"message"`
+	AppState        types.String `tfsdk:"appstate"`
+	AppAlarmed      types.Bool   `tfsdk:"app_alarmed"`
+	HWAlarmed       types.Bool   `tfsdk:"hw_alarmed"`
+	NCAlarmed       types.Bool   `tfsdk:"nc_alarmed"`
+	OSAlarmed       types.Bool   `tfsdk:"os_alarmed"`
+	ExpiresAt       types.String `tfsdk:"expires_at"`
+	LastUpdated     types.String `tfsdk:"last_updated"`
+	UpdatedBy       types.String `tfsdk:"updated_by"`
+	UpdatedByPuppet types.Bool   `tfsdk:"updated_by_puppet"`
+}
+
+func newStateRecord(state *roger.State) stateRecordModel {
+	m := stateRecordModel{
+		Hostname:        types.StringValue(state.Hostname),
+		Message:         types.StringNull(),
+		AppState:        types.StringValue(state.AppState),
+		AppAlarmed:      types.BoolValue(state.AppAlarmed),
+		HWAlarmed:       types.BoolValue(state.HWAlarmed),
+		NCAlarmed:       types.BoolValue(state.NCAlarmed),
+		OSAlarmed:       types.BoolValue(state.OSAlarmed),
+		ExpiresAt:       timeValue(state.ExpiresAt()),
+		LastUpdated:     timeValue(state.UpdatedAt()),
+		UpdatedBy:       types.StringValue(state.UpdatedBy),
+		UpdatedByPuppet: types.BoolValue(state.UpdatedByPuppet),
+	}
+	if state.Message != "" {
+		m.Message = types.StringValue(state.Message)
+	}
+	return m
+}
+
+type statesDataSource struct {
+	client *roger.Client
+}
+
+func (d *statesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_states"
+}
+
+func (d *statesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the roger states matching all of the given filters.",
+		Attributes: map[string]schema.Attribute{
+			"appstate": schema.StringAttribute{
+				Description: "Only return hosts in this appstate.",
+				Optional:    true,
+			},
+			"hostname_pattern": schema.StringAttribute{
+				Description: "Only return hosts whose name matches this shell pattern, e.g. 'batch*.cern.ch'.",
+				Optional:    true,
+			},
+			"app_alarmed": schema.BoolAttribute{
+				Description: "Only return hosts whose application alarms are enabled (true) or masked (false).",
+				Optional:    true,
+			},
+			"hw_alarmed": schema.BoolAttribute{
+				Description: "Only return hosts whose hardware alarms are enabled (true) or masked (false).",
+				Optional:    true,
+			},
+			"nc_alarmed": schema.BoolAttribute{
+				Description: "Only return hosts whose network connectivity alarms are enabled (true) or masked (false).",
+				Optional:    true,
+			},
+			"os_alarmed": schema.BoolAttribute{
+				Description: "Only return hosts whose operating system alarms are enabled (true) or masked (false).",
+				Optional:    true,
+			},
+			"hostnames": schema.ListAttribute{
+				Description: "Sorted names of the matching hosts.",
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"states": schema.MapNestedAttribute{
+				Description: "Matching roger entries keyed by hostname.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"hostname": schema.StringAttribute{
+							Description: "Name of the host.",
+							Computed:    true,
+						},
+						"message": schema.StringAttribute{
+							Description: "Alert Message",
+							Computed:    true,
+						},
+						"appstate": schema.StringAttribute{
+							Description: "Application state of the host.",
+							Computed:    true,
+						},
+						"app_alarmed": schema.BoolAttribute{
+							Description: "Whether application alarms of the host are enabled.",
+							Computed:    true,
+						},
+						"hw_alarmed": schema.BoolAttribute{
+							Description: "Whether hardware alarms of the host are enabled.",
+							Computed:    true,
+						},
+						"nc_alarmed": schema.BoolAttribute{
+							Description: "Whether network connectivity alarms of the host are enabled.",
+							Computed:    true,
+						},
+						"os_alarmed": schema.BoolAttribute{
+							Description: "Whether operating system alarms of the host are enabled.",
+							Computed:    true,
+						},
+						"expires_at": schema.StringAttribute{
+							Description: "Absolute expiry of the state reported by roger, in RFC3339 format.",
+							Computed:    true,
+						},
+						"last_updated": schema.StringAttribute{
+							Description: "Time of the last update of the state reported by roger, in RFC3339 format.",
+							Computed:    true,
+						},
+						"updated_by": schema.StringAttribute{
+							Description: "Account that last updated the state.",
+							Computed:    true,
+						},
+						"updated_by_puppet": schema.BoolAttribute{
+							Description: "Whether the state was last updated by puppet.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *statesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data statesDataSourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filter := roger.StateFilter{
+		AppState:   data.AppState.ValueString(),
+		Hostname:   data.HostnamePattern.ValueString(),
+		AppAlarmed: data.AppAlarmed.ValueBoolPointer(),
+		HWAlarmed:  data.HWAlarmed.ValueBoolPointer(),
+		NCAlarmed:  data.NCAlarmed.ValueBoolPointer(),
+		OSAlarmed:  data.OSAlarmed.ValueBoolPointer(),
+	}
+
+	states, err := d.client.ListStates(ctx, filter)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Listing roger states",
+			"Could not list roger states: "+err.Error(),
+		)
+		return
+	}
+
+	data.Hostnames = make([]string, 0, len(states))
+	data.States = make(map[string]stateRecordModel, len(states))
+	for i := range states {
+		data.Hostnames = append(data.Hostnames, states[i].Hostname)
+		data.States[states[i].Hostname] = newStateRecord(&states[i])
+	}
+	sort.Strings(data.Hostnames)
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (d *statesDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*roger.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *roger.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
@@ -0,0 +1,20 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	roger "roger/internal/client"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStateRecordMessage(t *testing.T) {
+	record := newStateRecord(&roger.State{Hostname: "host.cern.ch", AppState: "production"})
+	require.True(t, record.Message.IsNull())
+
+	record = newStateRecord(&roger.State{Hostname: "host.cern.ch", AppState: "draining", Message: "hardware repair"})
+	require.Equal(t, "hardware repair", record.Message.ValueString())
+}
@@ -0,0 +1,655 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	roger "roger/internal/client"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource               = &statesResource{}
+	_ resource.ResourceWithConfigure  = &statesResource{}
+	_ resource.ResourceWithModifyPlan = &statesResource{}
+)
+
+// defaultParallelism is the number of hosts roger_states changes at the same time unless configured otherwise.
+const defaultParallelism = 10
+
+// listStatesMinHosts is the number of hosts from which roger_states reads its hosts with a listing
+// rather than one by one.
+const listStatesMinHosts = 100
+
+func NewStatesResource() resource.Resource {
+	return &statesResource{}
+}
+
+type statesResourceModel struct {
+	ID          types.String              `tfsdk:"id"`
+	Parallelism types.Int64               `tfsdk:"parallelism"`
+	States      map[string]hostStateModel `tfsdk:"states"`
+}
+
+// hostStateModel is the state of a single host managed by roger_states.
+type hostStateModel struct {
+	AppState   types.String `tfsdk:"appstate"`
+	Message    types.String `tfsdk:"message"`
+	AppAlarmed types.Bool   `tfsdk:"app_alarmed"`
+	HWAlarmed  types.Bool   `tfsdk:"hw_alarmed"`
+	NCAlarmed  types.Bool   `tfsdk:"nc_alarmed"`
+	OSAlarmed  types.Bool   `tfsdk:"os_alarmed"`
+}
+
+func (m hostStateModel) equal(o hostStateModel) bool {
+	return m.AppState.Equal(o.AppState) &&
+		m.Message.Equal(o.Message) &&
+		m.AppAlarmed.Equal(o.AppAlarmed) &&
+		m.HWAlarmed.Equal(o.HWAlarmed) &&
+		m.NCAlarmed.Equal(o.NCAlarmed) &&
+		m.OSAlarmed.Equal(o.OSAlarmed)
+}
+
+// input builds the client request for hostname, only attributes that are set are sent.
+// As for roger_state, the message is cleared when it was removed from the configuration.
+func (m hostStateModel) input(hostname string, prior *hostStateModel) roger.StateInput {
+	input := roger.StateInput{
+		Hostname:   hostname,
+		AppState:   m.AppState.ValueStringPointer(),
+		AppAlarmed: knownBoolPointer(m.AppAlarmed),
+		HWAlarmed:  knownBoolPointer(m.HWAlarmed),
+		NCAlarmed:  knownBoolPointer(m.NCAlarmed),
+		OSAlarmed:  knownBoolPointer(m.OSAlarmed),
+	}
+	if !m.Message.IsNull() {
+		input.Message = m.Message.ValueStringPointer()
+	} else if prior != nil && !prior.Message.IsNull() {
+		input.Message = roger.Ptr("")
+	}
+	return input
+}
+
+// refresh copies the values reported by roger into the attributes that are managed.
+func (m *hostStateModel) refresh(state *roger.State) {
+	m.AppState = types.StringValue(state.AppState)
+	if !m.Message.IsNull() {
+		if state.Message != "" {
+			m.Message = types.StringValue(state.Message)
+		} else {
+			m.Message = types.StringNull()
+		}
+	}
+	if !m.AppAlarmed.IsNull() {
+		m.AppAlarmed = types.BoolValue(state.AppAlarmed)
+	}
+	if !m.HWAlarmed.IsNull() {
+		m.HWAlarmed = types.BoolValue(state.HWAlarmed)
+	}
+	if !m.NCAlarmed.IsNull() {
+		m.NCAlarmed = types.BoolValue(state.NCAlarmed)
+	}
+	if !m.OSAlarmed.IsNull() {
+		m.OSAlarmed = types.BoolValue(state.OSAlarmed)
+	}
+}
+
+type statesResource struct {
+	client *roger.Client
+}
+
+func (r *statesResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_states"
+}
+
+func (r *statesResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the roger states of a fleet of hosts. Existing roger entries are updated in place, only hosts whose configuration " +
+			"differs from roger are changed, and failures of single hosts are reported as errors without aborting the others. " +
+			"When a host is removed or the resource is destroyed, the entry found before the host was added is put back, or deleted if there was none.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Identifier of the set of hosts.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"parallelism": schema.Int64Attribute{
+				Description: fmt.Sprintf("Maximum number of hosts changed at the same time. Defaults to %d.", defaultParallelism),
+				Optional:    true,
+				Computed:    true,
+				Default:     int64default.StaticInt64(defaultParallelism),
+			},
+			"states": schema.MapNestedAttribute{
+				Description: "States of the hosts keyed by hostname.",
+				Required:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"appstate": schema.StringAttribute{
+							Description: "Application state of the host, e.g. 'production', 'draining' or 'quiesce'.",
+							Required:    true,
+						},
+						"message": schema.StringAttribute{
+							Description: "Alert Message",
+							Optional:    true,
+						},
+						"app_alarmed": schema.BoolAttribute{
+							Description: "Whether application alarms of the host are enabled. If not set, the value of roger is kept.",
+							Optional:    true,
+						},
+						"hw_alarmed": schema.BoolAttribute{
+							Description: "Whether hardware alarms of the host are enabled. If not set, the value of roger is kept.",
+							Optional:    true,
+						},
+						"nc_alarmed": schema.BoolAttribute{
+							Description: "Whether network connectivity alarms of the host are enabled. If not set, the value of roger is kept.",
+							Optional:    true,
+						},
+						"os_alarmed": schema.BoolAttribute{
+							Description: "Whether operating system alarms of the host are enabled. If not set, the value of roger is kept.",
+							Optional:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ModifyPlan rejects appstates that are not allowed and an invalid parallelism before anything is written to roger.
+// It also warns about removed hosts whose entry is left unchanged.
+func (r *statesResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	resp.Diagnostics.Append(warnUnrecordedRemovals(ctx, req)...)
+	if req.Plan.Raw.IsNull() || r.client == nil || resp.Diagnostics.HasError() {
+		return
+	}
+
+	var parallelism types.Int64
+	var planned types.Map
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("parallelism"), &parallelism)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("states"), &planned)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !parallelism.IsUnknown() && parallelism.ValueInt64() < 1 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("parallelism"),
+			"Invalid parallelism",
+			"parallelism must be at least 1.",
+		)
+	}
+
+	if planned.IsUnknown() {
+		return
+	}
+	var hosts map[string]hostStateModel
+	resp.Diagnostics.Append(planned.ElementsAs(ctx, &hosts, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	appStates, err := r.client.AppStates(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading roger appstates",
+			"Could not read the appstates accepted by roger: "+err.Error(),
+		)
+		return
+	}
+	for hostname, host := range hosts {
+		if host.AppState.IsUnknown() || slices.Contains(appStates, host.AppState.ValueString()) {
+			continue
+		}
+		resp.Diagnostics.AddAttributeError(
+			path.Root("states").AtMapKey(hostname).AtName("appstate"),
+			"Invalid roger appstate",
+			fmt.Sprintf("appstate %q is not one of %s. Sites with custom appstates can set allowed_appstates in the provider configuration.",
+				host.AppState.ValueString(), strings.Join(appStates, ", ")),
+		)
+	}
+}
+
+// warnUnrecordedRemovals warns about hosts that are removed from the resource although the entry found
+// before they were added is unknown, e.g. because they were added by an earlier version of the provider.
+// Their entry is left unchanged rather than deleted.
+func warnUnrecordedRemovals(ctx context.Context, req resource.ModifyPlanRequest) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if req.State.Raw.IsNull() {
+		return diags
+	}
+
+	var prior, planned types.Map
+	diags.Append(req.State.GetAttribute(ctx, path.Root("states"), &prior)...)
+	if !req.Plan.Raw.IsNull() {
+		diags.Append(req.Plan.GetAttribute(ctx, path.Root("states"), &planned)...)
+	}
+	if diags.HasError() || planned.IsUnknown() {
+		return diags
+	}
+
+	var previous map[string]*stateSnapshot
+	_, getDiags := getPrivateJSON(ctx, req.Private, previousStatesKey, &previous)
+	diags.Append(getDiags...)
+	if diags.HasError() {
+		return diags
+	}
+
+	var unrecorded []string
+	for hostname := range prior.Elements() {
+		_, kept := planned.Elements()[hostname]
+		_, recorded := previous[hostname]
+		if !kept && !recorded {
+			unrecorded = append(unrecorded, hostname)
+		}
+	}
+	if len(unrecorded) == 0 {
+		return diags
+	}
+	sort.Strings(unrecorded)
+	diags.AddAttributeWarning(
+		path.Root("states"),
+		"roger entries are left unchanged",
+		"The roger entries found before these hosts were added are unknown, e.g. because they were added by an earlier version "+
+			"of the provider. Removing them from roger_states leaves their entries unchanged: "+strings.Join(unrecorded, ", "),
+	)
+	return diags
+}
+
+func (r *statesResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan statesResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	hostnames := sortedHostnames(plan.States)
+	plan.ID = types.StringValue(hostsID(hostnames))
+
+	// Record the entries found before, so that they are put back when the hosts are removed.
+	var mu sync.Mutex
+	previous := make(map[string]*stateSnapshot, len(hostnames))
+	errs := forEachHost(ctx, hostnames, plan.Parallelism.ValueInt64(), func(ctx context.Context, hostname string) error {
+		snapshot, err := upsertState(ctx, r.client, plan.States[hostname].input(hostname, nil))
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		previous[hostname] = snapshot
+		mu.Unlock()
+		return nil
+	})
+
+	// Failed hosts are not stored. The errors taint the resource, replacing it puts back the entries
+	// recorded above before the hosts are changed again.
+	for hostname, err := range errs {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("states").AtMapKey(hostname),
+			"Error creating state",
+			"Could not create state of "+hostname+", unexpected error: "+err.Error(),
+		)
+		delete(plan.States, hostname)
+	}
+
+	resp.Diagnostics.Append(setPrivateJSON(ctx, resp.Private, previousStatesKey, previous)...)
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *statesResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var readState statesResourceModel
+	diags := req.State.Get(ctx, &readState)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Few hosts are read one by one. For many hosts a single listing is cheaper, narrowed to their appstate
+	// if they share one. Hosts missing from it, e.g. because their appstate changed or the listing failed,
+	// are read one by one before they are considered gone.
+	byHostname := make(map[string]*roger.State, len(readState.States))
+	if len(readState.States) >= listStatesMinHosts {
+		states, err := r.client.ListStates(ctx, sharedAppStateFilter(readState.States))
+		if err != nil {
+			tflog.Warn(ctx, "Could not list roger states, reading hosts one by one", map[string]any{
+				"error": err.Error(),
+			})
+		}
+		for i := range states {
+			if _, ok := readState.States[states[i].Hostname]; ok {
+				byHostname[states[i].Hostname] = &states[i]
+			}
+		}
+	}
+
+	var missing []string
+	for hostname := range readState.States {
+		if _, ok := byHostname[hostname]; !ok {
+			missing = append(missing, hostname)
+		}
+	}
+	sort.Strings(missing)
+
+	var mu sync.Mutex
+	errs := forEachHost(ctx, missing, readState.Parallelism.ValueInt64(), func(ctx context.Context, hostname string) error {
+		state, err := r.client.GetState(ctx, hostname)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		byHostname[hostname] = state
+		mu.Unlock()
+		return nil
+	})
+
+	for hostname, host := range readState.States {
+		if err, failed := errs[hostname]; failed {
+			if roger.IsNotFound(err) {
+				resp.Diagnostics.AddAttributeWarning(
+					path.Root("states").AtMapKey(hostname),
+					"roger state not found",
+					hostname+" has no roger entry anymore. It is removed from the Terraform state and created again on the next apply.",
+				)
+				delete(readState.States, hostname)
+				continue
+			}
+			resp.Diagnostics.AddAttributeError(
+				path.Root("states").AtMapKey(hostname),
+				"Error Reading roger state",
+				"Could not read roger state Hostname "+hostname+": "+err.Error(),
+			)
+			continue
+		}
+		host.refresh(byHostname[hostname])
+		readState.States[hostname] = host
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &readState)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *statesResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, prior statesResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only hosts that were added, changed or removed are sent to roger.
+	var changed []string
+	for hostname, host := range plan.States {
+		if previous, ok := prior.States[hostname]; !ok || !host.equal(previous) {
+			changed = append(changed, hostname)
+		}
+	}
+	for hostname := range prior.States {
+		if _, ok := plan.States[hostname]; !ok {
+			changed = append(changed, hostname)
+		}
+	}
+	sort.Strings(changed)
+
+	var previous map[string]*stateSnapshot
+	_, diags = getPrivateJSON(ctx, req.Private, previousStatesKey, &previous)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if previous == nil {
+		previous = make(map[string]*stateSnapshot, len(changed))
+	}
+
+	tflog.Debug(ctx, "Applying changed roger states", map[string]any{
+		"hosts": len(changed),
+	})
+
+	var mu sync.Mutex
+	errs := forEachHost(ctx, changed, plan.Parallelism.ValueInt64(), func(ctx context.Context, hostname string) error {
+		host, planned := plan.States[hostname]
+		priorHost, existed := prior.States[hostname]
+		switch {
+		case !planned:
+			mu.Lock()
+			snapshot, recorded := previous[hostname]
+			mu.Unlock()
+			if err := releaseState(ctx, r.client, hostname, snapshot, recorded); err != nil {
+				return err
+			}
+			mu.Lock()
+			delete(previous, hostname)
+			mu.Unlock()
+			return nil
+		case !existed:
+			snapshot, err := upsertState(ctx, r.client, host.input(hostname, nil))
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			previous[hostname] = snapshot
+			mu.Unlock()
+			return nil
+		default:
+			_, err := r.client.UpdateState(ctx, host.input(hostname, &priorHost))
+			return err
+		}
+	})
+
+	// Failed hosts keep their previous state, so that they are retried on the next apply.
+	for hostname, err := range errs {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("states").AtMapKey(hostname),
+			"Error Updating roger state",
+			"Could not update state of "+hostname+", unexpected error: "+err.Error(),
+		)
+		if previous, ok := prior.States[hostname]; ok {
+			plan.States[hostname] = previous
+		} else {
+			delete(plan.States, hostname)
+		}
+	}
+
+	resp.Diagnostics.Append(setPrivateJSON(ctx, resp.Private, previousStatesKey, previous)...)
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *statesResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state statesResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var previous map[string]*stateSnapshot
+	_, diags = getPrivateJSON(ctx, req.Private, previousStatesKey, &previous)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	errs := forEachHost(ctx, sortedHostnames(state.States), state.Parallelism.ValueInt64(), func(ctx context.Context, hostname string) error {
+		snapshot, recorded := previous[hostname]
+		return releaseState(ctx, r.client, hostname, snapshot, recorded)
+	})
+
+	if len(errs) == 0 {
+		return
+	}
+
+	// Keep the hosts that could not be deleted, so that they are retried.
+	remaining := make(map[string]hostStateModel, len(errs))
+	for hostname, err := range errs {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("states").AtMapKey(hostname),
+			"Error Releasing roger state",
+			"Could not restore or delete state of "+hostname+", unexpected error: "+err.Error(),
+		)
+		remaining[hostname] = state.States[hostname]
+	}
+	state.States = remaining
+
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// forEachHost calls fn for every hostname, running at most parallelism calls at the same time.
+// It returns the errors keyed by hostname. Once ctx is done no further calls are started,
+// the error of ctx is returned for the hosts that were skipped.
+func forEachHost(ctx context.Context, hostnames []string, parallelism int64, fn func(context.Context, string) error) map[string]error {
+	if parallelism < 1 {
+		parallelism = defaultParallelism
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = map[string]error{}
+		sem  = make(chan struct{}, parallelism)
+	)
+	acquire := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		select {
+		case sem <- struct{}{}:
+			// Both cases may be ready at the same time, do not start a call for a cancelled ctx.
+			if err := ctx.Err(); err != nil {
+				<-sem
+				return err
+			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for _, hostname := range hostnames {
+		if err := acquire(); err != nil {
+			mu.Lock()
+			errs[hostname] = err
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fn(ctx, hostname); err != nil {
+				mu.Lock()
+				errs[hostname] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// upsertState updates the roger entry of the host if it exists and creates it otherwise,
+// as most hosts already have an entry created by puppet. It returns the entry found before,
+// or nil if the host had none.
+func upsertState(ctx context.Context, client *roger.Client, input roger.StateInput) (*stateSnapshot, error) {
+	current, err := client.GetState(ctx, input.Hostname)
+	switch {
+	case err == nil:
+		_, err = client.UpdateState(ctx, input)
+		return roger.Ptr(snapshotOf(current)), err
+	case roger.IsNotFound(err):
+		_, err = client.CreateState(ctx, input)
+		return nil, err
+	default:
+		return nil, err
+	}
+}
+
+// releaseState puts back the entry found before the host was added to roger_states, or deletes
+// the entry if the host had none. If the previous entry was not recorded, the entry is left unchanged.
+func releaseState(ctx context.Context, client *roger.Client, hostname string, previous *stateSnapshot, recorded bool) error {
+	var err error
+	switch {
+	case !recorded:
+		tflog.Info(ctx, "No previous roger state recorded, leaving the entry unchanged", map[string]any{
+			"hostname": hostname,
+		})
+	case previous != nil:
+		_, err = client.UpdateState(ctx, previous.input(hostname))
+	default:
+		err = client.DeleteState(ctx, hostname)
+	}
+	if roger.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// sharedAppStateFilter filters on the appstate of the hosts if all of them have the same one.
+func sharedAppStateFilter(hosts map[string]hostStateModel) roger.StateFilter {
+	var appState string
+	for _, host := range hosts {
+		if appState != "" && host.AppState.ValueString() != appState {
+			return roger.StateFilter{}
+		}
+		appState = host.AppState.ValueString()
+	}
+	return roger.StateFilter{AppState: appState}
+}
+
+func sortedHostnames(states map[string]hostStateModel) []string {
+	hostnames := make([]string, 0, len(states))
+	for hostname := range states {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	return hostnames
+}
+
+// hostsID derives the identifier of roger_states from the hosts it was created with.
+func hostsID(hostnames []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(hostnames, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (r *statesResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*roger.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *roger.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
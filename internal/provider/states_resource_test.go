@@ -0,0 +1,290 @@
+// SPDX-FileCopyrightText: 2025 CERN
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEachHostLimitsConcurrencyAndCollectsErrors(t *testing.T) {
+	hostnames := make([]string, 20)
+	for i := range hostnames {
+		hostnames[i] = fmt.Sprintf("host%02d.cern.ch", i)
+	}
+
+	var active, peak, calls atomic.Int32
+	errs := forEachHost(context.Background(), hostnames, 3, func(_ context.Context, hostname string) error {
+		calls.Add(1)
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if hostname == "host03.cern.ch" || hostname == "host17.cern.ch" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	require.EqualValues(t, 20, calls.Load())
+	require.EqualValues(t, 3, peak.Load())
+	require.Len(t, errs, 2)
+	require.EqualError(t, errs["host03.cern.ch"], "failed")
+	require.EqualError(t, errs["host17.cern.ch"], "failed")
+}
+
+func TestForEachHostStopsWhenCancelled(t *testing.T) {
+	hostnames := []string{"host1.cern.ch", "host2.cern.ch", "host3.cern.ch"}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls atomic.Int32
+	errs := forEachHost(ctx, hostnames, 1, func(ctx context.Context, _ string) error {
+		calls.Add(1)
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	require.EqualValues(t, 1, calls.Load())
+	require.Len(t, errs, 3)
+	for _, hostname := range hostnames {
+		require.ErrorIs(t, errs[hostname], context.Canceled)
+	}
+}
+
+// applyStates applies the change of roger_states from prior to planned like terraform does, nil is a
+// resource that does not exist.
+func applyStates(t *testing.T, server tfprotov6.ProviderServer, prior, planned *statesResourceModel, private []byte) *tfprotov6.ApplyResourceChangeResponse {
+	t.Helper()
+	value := func(m *statesResourceModel) *tfprotov6.DynamicValue {
+		if m == nil {
+			return resourceValue(t, &statesResource{}, nil)
+		}
+		return resourceValue(t, &statesResource{}, m)
+	}
+
+	config := value(nil)
+	if planned != nil {
+		c := *planned
+		c.ID = types.StringNull()
+		config = value(&c)
+	}
+	resp, err := server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       "roger_states",
+		PriorState:     value(prior),
+		PlannedState:   value(planned),
+		Config:         config,
+		PlannedPrivate: private,
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestStatesRestoreAdoptedEntries(t *testing.T) {
+	fake := newFakeRoger(map[string]map[string]any{
+		"puppet.cern.ch": {"hostname": "puppet.cern.ch", "appstate": "production", "message": "managed by puppet", "hw_alarmed": true},
+	})
+	server := testServer(t, newTestClient(t, fake))
+
+	draining := hostStateModel{
+		AppState:  types.StringValue("draining"),
+		Message:   types.StringValue("kernel upgrade"),
+		HWAlarmed: types.BoolValue(false),
+	}
+	planned := statesResourceModel{
+		ID:          types.StringUnknown(),
+		Parallelism: types.Int64Value(2),
+		States: map[string]hostStateModel{
+			"puppet.cern.ch": draining,
+			"new1.cern.ch":   draining,
+			"new2.cern.ch":   draining,
+		},
+	}
+	created := applyStates(t, server, nil, &planned, nil)
+	require.Empty(t, created.Diagnostics)
+	require.Equal(t, "draining", fake.entry("puppet.cern.ch")["appstate"])
+	require.Equal(t, "draining", fake.entry("new1.cern.ch")["appstate"])
+
+	// Removing the adopted host puts back its entry.
+	var state statesResourceModel
+	resourceModel(t, &statesResource{}, created.NewState, &state)
+	removed := state
+	removed.States = maps.Clone(state.States)
+	delete(removed.States, "puppet.cern.ch")
+	updated := applyStates(t, server, &state, &removed, created.Private)
+	require.Empty(t, updated.Diagnostics)
+
+	entry := fake.entry("puppet.cern.ch")
+	require.Equal(t, "production", entry["appstate"])
+	require.Equal(t, "managed by puppet", entry["message"])
+	require.Equal(t, true, entry["hw_alarmed"])
+
+	// Destroying the resource deletes the entries it created and leaves the removed host alone.
+	fake.writes = nil
+	resourceModel(t, &statesResource{}, updated.NewState, &state)
+	destroyed := applyStates(t, server, &state, nil, updated.Private)
+	require.Empty(t, destroyed.Diagnostics)
+	require.ElementsMatch(t, []string{"DELETE new1.cern.ch", "DELETE new2.cern.ch"}, fake.writes)
+	require.NotNil(t, fake.entry("puppet.cern.ch"))
+}
+
+// statesPlan converts m to a plan of roger_states, nil is a null plan.
+func statesPlan(t *testing.T, m *statesResourceModel) tfsdk.Plan {
+	t.Helper()
+	ctx := context.Background()
+
+	var schemaResp resource.SchemaResponse
+	(&statesResource{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil)}
+	if m != nil {
+		require.False(t, plan.Set(ctx, m).HasError())
+	}
+	return plan
+}
+
+func TestStatesModifyPlanWarnsAboutUnrecordedHosts(t *testing.T) {
+	ctx := context.Background()
+	host := hostStateModel{AppState: types.StringValue("draining")}
+	prior := statesResourceModel{
+		ID:          types.StringValue("id"),
+		Parallelism: types.Int64Value(defaultParallelism),
+		States:      map[string]hostStateModel{"host1.cern.ch": host, "host2.cern.ch": host},
+	}
+	kept := prior
+	kept.States = map[string]hostStateModel{"host1.cern.ch": host}
+
+	tests := []struct {
+		name    string
+		plan    *statesResourceModel
+		removed string
+	}{
+		{"host removed", &kept, "host2.cern.ch"},
+		{"destroy", nil, "host1.cern.ch, host2.cern.ch"},
+		{"unchanged", &prior, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := statesPlan(t, tt.plan)
+			state := statesPlan(t, &prior)
+			req := resource.ModifyPlanRequest{Plan: plan, State: tfsdk.State(state)}
+			resp := resource.ModifyPlanResponse{Plan: plan}
+			(&statesResource{}).ModifyPlan(ctx, req, &resp)
+
+			require.False(t, resp.Diagnostics.HasError())
+			if tt.removed == "" {
+				require.Empty(t, resp.Diagnostics)
+				return
+			}
+			require.Len(t, resp.Diagnostics.Warnings(), 1)
+			require.Contains(t, resp.Diagnostics.Warnings()[0].Detail(), "leaves their entries unchanged: "+tt.removed)
+		})
+	}
+}
+
+func TestStatesCreateReportsFailedHosts(t *testing.T) {
+	fake := newFakeRoger(map[string]map[string]any{
+		"puppet.cern.ch": {"hostname": "puppet.cern.ch", "appstate": "production"},
+	})
+	fake.failing["broken.cern.ch"] = true
+	server := testServer(t, newTestClient(t, fake))
+
+	host := hostStateModel{AppState: types.StringValue("draining")}
+	planned := statesResourceModel{
+		ID:          types.StringUnknown(),
+		Parallelism: types.Int64Value(defaultParallelism),
+		States:      map[string]hostStateModel{"puppet.cern.ch": host, "broken.cern.ch": host},
+	}
+	created := applyStates(t, server, nil, &planned, nil)
+	require.Len(t, created.Diagnostics, 1)
+	require.Equal(t, tfprotov6.DiagnosticSeverityError, created.Diagnostics[0].Severity)
+	require.Contains(t, created.Diagnostics[0].Detail, "broken.cern.ch")
+
+	// The failed host is not stored, replacing the tainted resource puts back the adopted entry.
+	var state statesResourceModel
+	resourceModel(t, &statesResource{}, created.NewState, &state)
+	require.Equal(t, []string{"puppet.cern.ch"}, sortedHostnames(state.States))
+	require.Equal(t, "draining", fake.entry("puppet.cern.ch")["appstate"])
+
+	destroyed := applyStates(t, server, &state, nil, created.Private)
+	require.Empty(t, destroyed.Diagnostics)
+	require.Equal(t, "production", fake.entry("puppet.cern.ch")["appstate"])
+}
+
+// readStates refreshes prior with the entries of fake.
+func readStates(t *testing.T, fake *fakeRoger, prior statesResourceModel) (statesResourceModel, resource.ReadResponse) {
+	t.Helper()
+	ctx := context.Background()
+
+	state := tfsdk.State(statesPlan(t, &prior))
+	resp := resource.ReadResponse{State: state}
+	(&statesResource{client: newTestClient(t, fake)}).Read(ctx, resource.ReadRequest{State: state}, &resp)
+
+	var got statesResourceModel
+	require.False(t, resp.State.Get(ctx, &got).HasError())
+	return got, resp
+}
+
+func TestStatesReadFewHostsOneByOne(t *testing.T) {
+	fake := newFakeRoger(map[string]map[string]any{
+		"host1.cern.ch": {"hostname": "host1.cern.ch", "appstate": "production"},
+		"other.cern.ch": {"hostname": "other.cern.ch", "appstate": "production"},
+	})
+	host := hostStateModel{AppState: types.StringValue("draining")}
+	prior := statesResourceModel{
+		ID:          types.StringValue("id"),
+		Parallelism: types.Int64Value(defaultParallelism),
+		States:      map[string]hostStateModel{"host1.cern.ch": host, "gone.cern.ch": host},
+	}
+
+	got, resp := readStates(t, fake, prior)
+	require.False(t, resp.Diagnostics.HasError())
+	require.Len(t, resp.Diagnostics.Warnings(), 1)
+	require.Empty(t, fake.lists)
+	require.Equal(t, map[string]hostStateModel{
+		"host1.cern.ch": {AppState: types.StringValue("production")},
+	}, got.States)
+}
+
+func TestStatesReadManyHostsWithFilteredListing(t *testing.T) {
+	fake := newFakeRoger(nil)
+	prior := statesResourceModel{
+		ID:          types.StringValue("id"),
+		Parallelism: types.Int64Value(defaultParallelism),
+		States:      map[string]hostStateModel{},
+	}
+	for i := range listStatesMinHosts {
+		hostname := fmt.Sprintf("batch%03d.cern.ch", i)
+		fake.entries[hostname] = map[string]any{"hostname": hostname, "appstate": "draining"}
+		prior.States[hostname] = hostStateModel{AppState: types.StringValue("draining")}
+	}
+	// Missing from the listing, as its appstate changed.
+	fake.entries["batch007.cern.ch"]["appstate"] = "production"
+
+	got, resp := readStates(t, fake, prior)
+	require.Empty(t, resp.Diagnostics)
+	require.Equal(t, []string{"appstate=draining"}, fake.lists)
+	require.Len(t, got.States, listStatesMinHosts)
+	require.Equal(t, "production", got.States["batch007.cern.ch"].AppState.ValueString())
+	require.Equal(t, "draining", got.States["batch008.cern.ch"].AppState.ValueString())
+}